@@ -0,0 +1,55 @@
+package cache
+
+import "time"
+
+// NewWithAdaptiveCleanup creates a Cache whose cleanup interval tunes itself to
+// the expiry pressure, between minInterval and maxInterval; see
+// WithAdaptiveCleanup. cleanerInterval is the starting point.
+func NewWithAdaptiveCleanup(cleanerInterval, minInterval, maxInterval time.Duration) *Cache {
+	return NewWithOptions(cleanerInterval, WithAdaptiveCleanup(minInterval, maxInterval))
+}
+
+// WithAdaptiveCleanup lets the background cleanup interval drift within
+// [minInterval, maxInterval] instead of staying at cleanerInterval, which is
+// clamped into that range and used for the first sweep. After a sweep that
+// reaped nothing the interval doubles, so an idle cache is scanned less and
+// less often; after a sweep that reaped more than the one before it the
+// interval halves, so a growing backlog of expired entries is caught up
+// quickly; otherwise it stays put. Jitter, if configured, applies on top.
+// Paused ticks leave the interval alone. It has no effect unless
+// cleanerInterval > 0 starts the cleanup goroutine, and bounds with
+// maxInterval <= 0 or minInterval > maxInterval are ignored.
+func WithAdaptiveCleanup(minInterval, maxInterval time.Duration) Option {
+	return func(c *Cache) {
+		if maxInterval <= 0 || minInterval > maxInterval {
+			return
+		}
+		c.minInterval = max(minInterval, 1)
+		c.maxInterval = maxInterval
+	}
+}
+
+// adaptiveInterval returns the current adaptive interval, starting from
+// cleanerInterval clamped to the bounds.
+func (c *Cache) adaptiveInterval() time.Duration {
+	if c.interval == 0 {
+		c.interval = min(max(c.cleanerInterval, c.minInterval), c.maxInterval)
+	}
+	return c.interval
+}
+
+// adapt moves the adaptive interval according to the number of entries the
+// latest background sweep reaped.
+func (c *Cache) adapt(reaped int) {
+	if c.maxInterval <= 0 {
+		return
+	}
+	d := c.adaptiveInterval()
+	switch {
+	case reaped == 0:
+		d = min(2*d, c.maxInterval)
+	case reaped > c.lastReaped:
+		d = max(d/2, c.minInterval)
+	}
+	c.interval, c.lastReaped = d, reaped
+}
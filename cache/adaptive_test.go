@@ -0,0 +1,55 @@
+package cache
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestAdaptiveCleanupInterval(t *testing.T) {
+	// No goroutine: the test drives the sweeps itself.
+	c := NewWithOptions(0, WithAdaptiveCleanup(time.Second, 8*time.Second))
+	defer c.Stop()
+	c.cleanerInterval = 2 * time.Second // the starting point a running cache would have
+
+	if d := c.nextCleanup(); d != 2*time.Second {
+		t.Fatalf("expected to start at cleanerInterval, got %v", d)
+	}
+	for _, want := range []time.Duration{4 * time.Second, 8 * time.Second, 8 * time.Second} {
+		c.backgroundSweep(time.Now())
+		if d := c.nextCleanup(); d != want {
+			t.Fatalf("expected idle sweeps to lengthen the interval to %v, got %v", want, d)
+		}
+	}
+
+	expire := func(n int) {
+		for i := 0; i < n; i++ {
+			c.Set("k"+strconv.Itoa(i), i, time.Nanosecond)
+		}
+		time.Sleep(time.Millisecond)
+		c.backgroundSweep(time.Now())
+	}
+	for _, step := range []struct {
+		reaped int
+		want   time.Duration
+	}{{5, 4 * time.Second}, {10, 2 * time.Second}, {10, 2 * time.Second}, {20, time.Second}, {50, time.Second}, {3, time.Second}} {
+		expire(step.reaped)
+		if d := c.nextCleanup(); d != step.want {
+			t.Fatalf("after reaping %d: expected %v, got %v", step.reaped, step.want, d)
+		}
+	}
+
+	c.PauseCleanup()
+	c.backgroundSweep(time.Now())
+	if d := c.nextCleanup(); d != time.Second {
+		t.Fatalf("expected a paused tick to leave the interval alone, got %v", d)
+	}
+}
+
+func TestAdaptiveCleanupRejectsBadBounds(t *testing.T) {
+	c := NewWithOptions(0, WithAdaptiveCleanup(time.Minute, time.Second))
+	defer c.Stop()
+	if c.maxInterval != 0 {
+		t.Fatalf("expected inverted bounds to be ignored")
+	}
+}
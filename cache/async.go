@@ -0,0 +1,108 @@
+package cache
+
+import "time"
+
+// asyncQueueSize is the number of AsyncSet writes buffered before AsyncSet
+// blocks.
+const asyncQueueSize = 1024
+
+// asyncWrite is one queued AsyncSet, or a WaitAsync marker when flushed is set.
+type asyncWrite struct {
+	key     string
+	e       entry
+	flushed chan struct{} // Closed by the drainer once every earlier write is applied
+}
+
+// AsyncSet queues a Set of key and returns without waiting for the write lock,
+// so bursts of writes do not block their callers behind readers and other
+// writers. A dedicated goroutine, started on first use, applies queued writes in
+// order, several per lock acquisition. The TTL counts from the AsyncSet call,
+// and in copy mode (see NewWithCopy) the value is copied before AsyncSet
+// returns, so the caller may modify it straight away.
+//
+// The write is not visible until the queue drains: a Get immediately after
+// AsyncSet may miss or return the previous value. Call WaitAsync to wait for
+// queued writes. If asyncQueueSize writes are already pending, AsyncSet blocks
+// until there is room. Stop applies every write queued before it; an AsyncSet
+// after Stop is applied synchronously, as Set.
+//
+// Evictions caused by queued writes run their OnEvict and OnEvictBatch
+// callbacks on the draining goroutine. Such callbacks must not call AsyncSet,
+// WaitAsync or Stop on the same cache: each of them can wait for the drainer,
+// which is busy running the callback, and would deadlock.
+func (c *Cache) AsyncSet(key string, value interface{}, ttl time.Duration) {
+	key = c.normalize(key)
+	e := entry{value: c.copyIn(value), expiresAt: c.expiry(c.now(), ttl)}
+	if !c.enqueue(asyncWrite{key: key, e: e}) {
+		c.mu.Lock()
+		defer c.unlock()
+		c.storeLocked(key, e)
+	}
+}
+
+// WaitAsync waits until every AsyncSet queued before it has been applied.
+func (c *Cache) WaitAsync() {
+	done := make(chan struct{})
+	if c.enqueue(asyncWrite{flushed: done}) {
+		<-done
+	}
+}
+
+// enqueue hands w to the drainer, starting it on first use. It reports false
+// once Stop has closed the queue.
+func (c *Cache) enqueue(w asyncWrite) bool {
+	c.asyncMu.RLock()
+	defer c.asyncMu.RUnlock()
+	if c.asyncClosed {
+		return false
+	}
+	c.asyncOnce.Do(func() {
+		c.asyncQ = make(chan asyncWrite, asyncQueueSize)
+		c.asyncDone = make(chan struct{})
+		go c.drainAsync()
+	})
+	c.asyncQ <- w
+	return true
+}
+
+// drainAsync applies queued writes until the queue is closed, taking the write
+// lock once for each run of writes already waiting.
+func (c *Cache) drainAsync() {
+	defer close(c.asyncDone)
+	for w := range c.asyncQ {
+		var flushed []chan struct{}
+		c.mu.Lock()
+		for {
+			if w.flushed != nil {
+				flushed = append(flushed, w.flushed)
+			} else {
+				c.storeLocked(w.key, w.e)
+			}
+			var ok bool
+			select {
+			case w, ok = <-c.asyncQ:
+			default:
+			}
+			if !ok {
+				break
+			}
+		}
+		c.unlock()
+		for _, done := range flushed {
+			close(done)
+		}
+	}
+}
+
+// stopAsync closes the AsyncSet queue and waits for the drainer to apply what
+// remains in it.
+func (c *Cache) stopAsync() {
+	c.asyncMu.Lock()
+	c.asyncClosed = true
+	q := c.asyncQ
+	c.asyncMu.Unlock()
+	if q != nil {
+		close(q)
+		<-c.asyncDone
+	}
+}
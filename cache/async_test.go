@@ -0,0 +1,52 @@
+package cache
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAsyncSetVisibleAfterWaitAsync(t *testing.T) {
+	c := NewManual()
+	defer c.Stop()
+
+	for i := 0; i < 100; i++ {
+		c.AsyncSet("k"+strconv.Itoa(i), i, 0)
+	}
+	c.AsyncSet("k0", "last", time.Hour)
+	c.WaitAsync()
+	for i := 1; i < 100; i++ {
+		if v, ok := c.Get("k" + strconv.Itoa(i)); !ok || v != i {
+			t.Fatalf("k%d: expected %d after WaitAsync, got %v ok=%v", i, i, v, ok)
+		}
+	}
+	if v, ttl, ok := c.GetWithTTL("k0"); !ok || v != "last" || ttl <= 0 {
+		t.Fatalf("expected queued writes applied in order, got %v ttl=%v ok=%v", v, ttl, ok)
+	}
+}
+
+func TestStopDrainsAsyncSet(t *testing.T) {
+	c := NewManual()
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				c.AsyncSet(strconv.Itoa(g)+"-"+strconv.Itoa(i), i, 0)
+			}
+		}(g)
+	}
+	wg.Wait()
+	c.Stop()
+	if n := c.Len(); n != 2000 {
+		t.Fatalf("expected Stop to apply every queued write, have %d of 2000", n)
+	}
+
+	c.AsyncSet("late", 1, 0)
+	if v, ok := c.Get("late"); !ok || v != 1 {
+		t.Fatalf("expected AsyncSet after Stop to apply synchronously, got %v ok=%v", v, ok)
+	}
+	c.WaitAsync()
+}
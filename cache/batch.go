@@ -0,0 +1,125 @@
+package cache
+
+import "time"
+
+// GetMulti looks up several keys at once and returns the live ones; absent,
+// expired and negative keys are left out of the result. It is GetBatch without
+// the list of misses.
+func (c *Cache) GetMulti(keys []string) map[string]interface{} {
+	found, _ := c.GetBatch(keys)
+	return found
+}
+
+// GetBatch looks up several keys at once and partitions them: hits holds the
+// live values, and misses lists, in request order, the keys that are absent or
+// expired, ready for a downstream batch fetch. Keys holding a negative entry
+// (SetNegative) are in neither, since they are already known not to exist
+// upstream. Hits, misses, lazy expiry and sliding renewal behave exactly as for
+// Get, but the lock is taken once for the whole batch (plus one write-lock pass
+// if any entry needs reaping or renewal). Both results use the keys as passed
+// in, even when a key function normalizes them.
+func (c *Cache) GetBatch(keys []string) (hits map[string]interface{}, misses []string) {
+	now := c.now()
+	found := make(map[string]interface{}, len(keys))
+	if c.tracked.Load() {
+		c.mu.Lock()
+		defer c.unlock()
+		for _, k := range keys {
+			e, state := c.getLocked(c.normalize(k), now)
+			if state != StateHit {
+				misses = append(misses, k)
+				continue
+			}
+			e.accessed.Store(now.UnixNano())
+			if !e.negative {
+				found[k] = c.copyOut(e.value)
+			}
+		}
+		return found, misses
+	}
+
+	// Entries that were expired, sliding or due for revalidation when read,
+	// keyed by the deadline seen.
+	var fixups map[string]time.Time
+	c.mu.RLock()
+	revalidate := c.revalidator != nil
+	for _, k := range keys {
+		nk := c.normalize(k)
+		e, ok := c.data[nk]
+		if !ok {
+			c.misses.Add(1)
+			misses = append(misses, k)
+			continue
+		}
+		if e.expired(now) {
+			c.misses.Add(1)
+			misses = append(misses, k)
+		} else {
+			c.countFound(e)
+			e.accessed.Store(now.UnixNano())
+			if !e.negative {
+				found[k] = c.copyOut(e.value)
+			}
+			if e.sliding <= 0 && !(revalidate && e.stale(now) && !e.refreshing) {
+				continue
+			}
+		}
+		if fixups == nil {
+			fixups = make(map[string]time.Time)
+		}
+		fixups[nk] = e.expiresAt
+	}
+	c.mu.RUnlock()
+
+	if len(fixups) > 0 {
+		c.mu.Lock()
+		for k, seen := range fixups {
+			// Skip entries that were replaced or renewed since the read pass.
+			e, ok := c.data[k]
+			if !ok || !e.expiresAt.Equal(seen) {
+				continue
+			}
+			switch {
+			case e.expired(now):
+				c.removeLocked(k, e, ReasonExpired)
+			case e.sliding > 0:
+				c.setExpiryLocked(k, e, c.expiry(now, e.sliding))
+			case e.stale(now):
+				c.revalidateLocked(k, e)
+			}
+		}
+		c.unlock()
+	}
+	return found, misses
+}
+
+// SetMulti stores every item with the same TTL under a single write-lock acquisition.
+// If ttl <= 0, the items never expire. With TTL jitter each item's TTL is varied
+// on its own, so a batch does not expire all at once.
+func (c *Cache) SetMulti(items map[string]interface{}, ttl time.Duration) {
+	now := c.now()
+	c.mu.Lock()
+	defer c.unlock()
+	for k, v := range items {
+		c.setLocked(c.normalize(k), entry{value: v, expiresAt: c.expiry(now, ttl), createdAt: now})
+	}
+}
+
+// Item is one entry of a SetItems batch.
+type Item struct {
+	Key   string
+	Value interface{}
+	TTL   time.Duration // <= 0 means the item never expires
+}
+
+// SetItems stores every item with its own TTL under a single write-lock
+// acquisition. Items are applied in order, so a key repeated within the batch
+// ends up with its last value.
+func (c *Cache) SetItems(items []Item) {
+	now := c.now()
+	c.mu.Lock()
+	defer c.unlock()
+	for _, it := range items {
+		c.setLocked(c.normalize(it.Key), entry{value: it.Value, expiresAt: c.expiry(now, it.TTL), createdAt: now})
+	}
+}
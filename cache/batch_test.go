@@ -0,0 +1,88 @@
+package cache
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGetMultiSetMulti(t *testing.T) {
+	for _, capacity := range []int{0, 10} {
+		c := NewWithCapacity(time.Hour, capacity)
+
+		c.SetMulti(map[string]interface{}{"a": 1, "b": 2}, time.Minute)
+		c.Set("gone", 3, time.Millisecond)
+		time.Sleep(5 * time.Millisecond)
+
+		got := c.GetMulti([]string{"a", "b", "gone", "missing"})
+		if len(got) != 2 || got["a"] != 1 || got["b"] != 2 {
+			t.Fatalf("capacity=%d: expected a and b, got %v", capacity, got)
+		}
+		c.mu.RLock()
+		_, present := c.data["gone"]
+		c.mu.RUnlock()
+		if present {
+			t.Fatalf("capacity=%d: expected GetMulti to reap expired key", capacity)
+		}
+		if s := c.Stats(); s.Hits != 2 || s.Misses != 2 || s.Expirations != 1 {
+			t.Fatalf("capacity=%d: unexpected stats %+v", capacity, s)
+		}
+		c.Stop()
+	}
+}
+
+func TestGetMultiRenewsSliding(t *testing.T) {
+	c := New(time.Hour)
+	defer c.Stop()
+
+	c.SetSliding("s", 1, 30*time.Millisecond)
+	for i := 0; i < 3; i++ {
+		time.Sleep(15 * time.Millisecond)
+		if got := c.GetMulti([]string{"s"}); got["s"] != 1 {
+			t.Fatalf("sliding entry expired during batch reads (iteration %d)", i)
+		}
+	}
+}
+
+func TestGetBatchReportsMisses(t *testing.T) {
+	for _, capacity := range []int{0, 10} {
+		c := NewWithCapacity(time.Hour, capacity)
+
+		c.Set("a", 1, 0)
+		c.Set("gone", 2, time.Millisecond)
+		c.SetNegative("neg", time.Minute)
+		time.Sleep(5 * time.Millisecond)
+
+		hits, misses := c.GetBatch([]string{"missing", "a", "gone", "neg"})
+		if len(hits) != 1 || hits["a"] != 1 {
+			t.Fatalf("capacity=%d: expected only a to hit, got %v", capacity, hits)
+		}
+		if !reflect.DeepEqual(misses, []string{"missing", "gone"}) {
+			t.Fatalf("capacity=%d: expected missing and gone in request order, got %v", capacity, misses)
+		}
+		c.Stop()
+	}
+}
+
+func TestSetItemsPerItemTTL(t *testing.T) {
+	c := New(time.Hour)
+	defer c.Stop()
+
+	c.SetItems([]Item{
+		{Key: "short", Value: 1, TTL: time.Millisecond},
+		{Key: "long", Value: 2, TTL: time.Minute},
+		{Key: "forever", Value: 3},
+		{Key: "long", Value: 4, TTL: time.Minute},
+	})
+	time.Sleep(5 * time.Millisecond)
+
+	if _, ok := c.Get("short"); ok {
+		t.Fatalf("expected short to expire on its own TTL")
+	}
+	if v, ttl, _ := c.GetWithTTL("long"); v != 4 || ttl <= 0 {
+		t.Fatalf("expected the last write of long with its TTL, got %v (ttl %v)", v, ttl)
+	}
+	if _, ttl, ok := c.GetWithTTL("forever"); !ok || ttl != 0 {
+		t.Fatalf("expected forever never to expire, got %v ok=%v", ttl, ok)
+	}
+}
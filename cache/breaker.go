@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned instead of calling a loader while the cache's
+// circuit breaker (see WithCircuitBreaker) is open.
+var ErrCircuitOpen = errors.New("cache: loader circuit open")
+
+// breaker counts consecutive loader failures for one cache. A nil *breaker is
+// a disabled breaker: it allows every load and records nothing.
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu        sync.Mutex
+	failures  int       // Consecutive failed loads
+	openUntil time.Time // While failures >= threshold, loads are refused until then
+	trial     bool      // A trial load is in flight after the cooldown
+}
+
+// allow reports whether a load may run at now, returning ErrCircuitOpen if
+// not. Once the cooldown has passed, exactly one caller is let through as the
+// trial load; the rest are refused until record settles it.
+func (b *breaker) allow(now time.Time) error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures < b.threshold {
+		return nil
+	}
+	if now.Before(b.openUntil) || b.trial {
+		return ErrCircuitOpen
+	}
+	b.trial = true
+	return nil
+}
+
+// record tallies the outcome of a load that allow let through. ErrNotFound is
+// a definite answer from the backend rather than a failure, so it counts as a
+// success. context.Canceled, from a GetContext load whose callers all gave up,
+// says nothing about the backend and counts as neither.
+func (b *breaker) record(now time.Time, err error) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	if err == nil || errors.Is(err, ErrNotFound) {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openUntil = now.Add(b.cooldown)
+	}
+}
+
+// NewWithCircuitBreaker creates a Cache whose loaders are guarded by a circuit
+// breaker; see WithCircuitBreaker.
+func NewWithCircuitBreaker(cleanerInterval time.Duration, threshold int, cooldown time.Duration) *Cache {
+	return NewWithOptions(cleanerInterval, WithCircuitBreaker(threshold, cooldown))
+}
+
+// WithCircuitBreaker protects the backend behind the cache's loaders during an
+// outage. After threshold consecutive failed loads, GetOrSet, GetOrLoad,
+// GetContext and read-through misses stop calling their loader for cooldown and
+// fail fast with ErrCircuitOpen instead. The first miss after the cooldown runs
+// a single trial load while other misses keep failing fast: if the trial
+// succeeds the breaker closes, and if it fails the breaker opens for another
+// cooldown. Hits are never affected, and concurrent misses on one key still
+// share a single load, so they count as one success or failure.
+//
+// The breaker is shared by every key of the cache. A loader returning
+// ErrNotFound is not a failure, and neither is a GetContext load cancelled
+// because every caller gave up. A threshold <= 0 disables the breaker.
+func WithCircuitBreaker(threshold int, cooldown time.Duration) Option {
+	return func(c *Cache) {
+		if threshold <= 0 {
+			c.breaker = nil
+			return
+		}
+		c.breaker = &breaker{threshold: threshold, cooldown: cooldown}
+	}
+}
@@ -0,0 +1,93 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/UtkrushtApps/go-concurrent-cache-answers/cache/cachetest"
+)
+
+func TestCircuitBreakerOpensAndRecovers(t *testing.T) {
+	clock := cachetest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	c := NewWithOptions(0, WithClock(clock), WithCircuitBreaker(3, time.Minute))
+	defer c.Stop()
+
+	down := errors.New("backend down")
+	calls := 0
+	failing := func() (interface{}, error) { calls++; return nil, down }
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.GetOrSet("k", 0, failing); err != down {
+			t.Fatalf("attempt %d: expected the loader error, got %v", i, err)
+		}
+	}
+	if _, err := c.GetOrSet("other", 0, failing); err != ErrCircuitOpen {
+		t.Fatalf("expected the open breaker to fail fast for every key, got %v", err)
+	}
+	if calls != 3 {
+		t.Fatalf("expected no loader call while open, got %d calls", calls)
+	}
+
+	// After the cooldown one failed trial reopens the breaker.
+	clock.Advance(time.Minute)
+	if _, err := c.GetOrSet("k", 0, failing); err != down {
+		t.Fatalf("expected a trial load after the cooldown, got %v", err)
+	}
+	if _, err := c.GetOrSet("k", 0, failing); err != ErrCircuitOpen {
+		t.Fatalf("expected a failed trial to reopen the breaker, got %v", err)
+	}
+
+	// A successful trial closes it again.
+	clock.Advance(time.Minute)
+	if v, err := c.GetOrSet("k", 0, func() (interface{}, error) { return 1, nil }); err != nil || v != 1 {
+		t.Fatalf("expected the trial to load, got %v, %v", v, err)
+	}
+	if _, err := c.GetOrSet("other", 0, failing); err != down {
+		t.Fatalf("expected a closed breaker to call the loader, got %v", err)
+	}
+	if v, _ := c.Get("k"); v != 1 {
+		t.Fatalf("expected hits to be unaffected by the breaker, got %v", v)
+	}
+}
+
+func TestCircuitBreakerIgnoresNotFound(t *testing.T) {
+	c := NewWithCircuitBreaker(0, 1, time.Hour)
+	defer c.Stop()
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.GetOrSet("k", 0, func() (interface{}, error) { return nil, ErrNotFound }); err != ErrNotFound {
+			t.Fatalf("expected ErrNotFound not to trip the breaker, got %v", err)
+		}
+	}
+}
+
+func TestCircuitBreakerIgnoresAbandonedLoads(t *testing.T) {
+	c := NewWithCircuitBreaker(0, 1, time.Hour)
+	defer c.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	load := make(chan *call, 1)
+	go func() {
+		<-started
+		c.loadMu.Lock()
+		load <- c.loads["k"]
+		c.loadMu.Unlock()
+		cancel()
+	}()
+	_, err := c.GetContext(ctx, "k", 0, func(ctx context.Context) (interface{}, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	if err != context.Canceled {
+		t.Fatalf("expected the caller to see its cancellation, got %v", err)
+	}
+	<-(<-load).done // the abandoned load has been recorded
+
+	if v, err := c.GetOrSet("other", 0, func() (interface{}, error) { return 1, nil }); err != nil || v != 1 {
+		t.Fatalf("expected an abandoned load not to trip the breaker, got %v %v", v, err)
+	}
+}
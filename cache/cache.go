@@ -1,112 +1,1103 @@
 package cache
 
 import (
+	"container/heap"
+	"container/list"
 	"context"
+	"log/slog"
+	"math"
+	"math/rand"
+	"runtime"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // entry wraps a value with its expiration time.
 type entry struct {
-	value      interface{}
-	expiresAt  time.Time // If zero value, never expires
+	value     interface{}
+	expiresAt time.Time     // If zero value, never expires
+	elem      *list.Element // Position in the LRU list; nil for unbounded caches
+	sliding   time.Duration // If > 0, each Get pushes expiresAt to now+sliding
+	size      int64         // Estimated size in bytes, as reported by the sizer
+
+	// Stale-while-revalidate state, set by SetWithStale. Past staleAt the value is
+	// served as stale until expiresAt.
+	staleAt    time.Time
+	freshFor   time.Duration
+	staleFor   time.Duration
+	refreshing bool // A revalidation is in flight
+
+	tags    []string // Tags indexed in Cache.tags
+	parents []string // Keys the entry depends on, indexed in Cache.dependents
+
+	weight int           // Eviction weight set by SetWeighted; 0 for everything else
+	welem  *list.Element // Position in Cache.weighted[weight]; nil for unbounded caches
+	oelem  *list.Element // Position in Cache.order; nil unless in insertion-order mode
+
+	negative bool  // A cached "not found"; value is nil
+	err      error // For a negative entry, the error to replay; nil means ErrNotFound
+
+	refresh *refreshJob // The SetRefreshing registration keeping the entry fresh, if any
+
+	createdAt time.Time     // When the current value was stored
+	version   uint64        // Generation of the current value; see GetVersioned
+	accessed  *atomic.Int64 // UnixNano of the last Get hit, 0 if never; shared by copies of the entry
+}
+
+// expired reports whether the entry has a deadline that lies before now.
+func (e entry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && now.After(e.expiresAt)
+}
+
+// stale reports whether the entry is past its fresh window at now.
+func (e entry) stale(now time.Time) bool {
+	return !e.staleAt.IsZero() && now.After(e.staleAt)
 }
 
 // Cache is a concurrent, in-memory key-value cache with per-entry TTL and background cleanup.
 type Cache struct {
-	mu      sync.RWMutex
-	data    map[string]entry
-	wg      sync.WaitGroup
-	cancel  context.CancelFunc
-	ctx     context.Context
+	mu              sync.RWMutex
+	data            map[string]entry
+	generation      uint64 // Last version handed out by setLocked; guarded by mu
+	sizeHint        int    // Initial capacity of data; see NewWithSize
+	wg              sync.WaitGroup
+	cancel          context.CancelFunc
+	ctx             context.Context
 	cleanerInterval time.Duration
+	stopOnce        sync.Once
+	paused          atomic.Bool // Background sweeps are skipped while set; see PauseCleanup
+	frozen          atomic.Bool // Writes and deletes are refused while set; see Freeze
+
+	maxEntries int         // <= 0 means unbounded
+	lru        *list.List  // Front is most recently used; holds keys. Nil when unbounded.
+	tracked    atomic.Bool // lru or policy is set, so reads must take the write lock; readable without mu
+
+	policy EvictionPolicy // Replaces lru for eviction when set; see NewWithPolicy
+
+	weighted map[int]*list.List // Recency lists of entries by weight; guarded by mu
+
+	order *list.List // Keys in insertion order, oldest first; nil unless NewWithInsertionOrder
+
+	jitter float64 // Fraction of cleanerInterval by which each sweep delay varies
+
+	// Adaptive cleanup bounds set by WithAdaptiveCleanup, and the state the
+	// cleanup goroutine keeps between sweeps; interval and lastReaped are only
+	// touched by that goroutine.
+	minInterval, maxInterval time.Duration
+	interval                 time.Duration // Current delay; zero until the first sweep
+	lastReaped               int
+
+	copyValues bool // Deep-copy values on the way in and out; see NewWithCopy
+
+	cleanupBatch int           // Max heap nodes per cleanup lock hold; <= 0 means unbounded
+	sampleEvery  uint64        // Reads between expiry samples; 0 disables sampling
+	sampleSize   int           // Entries examined per expiry sample
+	sampleReads  atomic.Uint64 // Reads counted towards the next sample
+	defaultTTL   time.Duration // TTL used by SetDefault
+	maxTTL       time.Duration // Upper bound on every entry's lifetime; <= 0 means none
+	ttlJitter    float64       // Fraction by which each positive TTL is randomly varied
+
+	keyFunc func(string) string // Normalizes keys before they reach data; nil means identity
+	clock   Clock               // Source of the current time for expiry; nil means the system clock
+
+	maxBytes     int64                         // <= 0 means no byte budget
+	maxValueSize int64                         // Largest size of a storable value; <= 0 means no limit
+	sizer        func(value interface{}) int64 // Nil means defaultEntrySize per entry
+	bytes        atomic.Int64                  // Sum of entry sizes; written under mu
+
+	expiries expiryHeap // Pending deadlines, earliest first; guarded by mu
+
+	tags map[string]map[string]struct{} // Tag to tagged keys; guarded by mu
+
+	dependents map[string]map[string]struct{} // Parent key to the keys depending on it; guarded by mu
+	cascade    map[string]struct{}            // Keys reached by the invalidation in progress; guarded by mu
+
+	hits, misses, evictions, expirations atomic.Uint64
+	lastCleanup                          atomic.Int64 // Duration of the latest sweep
+	lastScanned, lastRemoved             atomic.Int64 // Heap nodes examined and entries removed by it
+	lastCleanupAt                        atomic.Int64 // Wall-clock Unix nanoseconds at which it finished
+	peakEntries, peakBytes               atomic.Int64 // High-water marks of len(data) and bytes; written under mu
+
+	revalidator func(key string) (interface{}, error) // Guarded by mu
+
+	onEvict []func(evicted)                     // Guarded by mu
+	onBatch []func([]EvictedItem)               // OnEvictBatch callbacks; guarded by mu
+	onSwept func(key string, value interface{}) // ForEachExpired hook; guarded by mu
+	logger  *slog.Logger                        // Debug log of removals, sweeps and load errors; nil means none
+	pending []evicted                           // Callbacks to run on unlock; guarded by mu
+	events  []Event                             // Events to publish on unlock; guarded by mu
+
+	subMu       sync.Mutex               // Guards subs; taken after mu, never before
+	subs        map[*subscriber]struct{} // Active subscriptions
+	subscribers atomic.Int32             // len(subs), readable under mu without subMu
+	dropped     atomic.Uint64            // Events dropped because a subscriber was full
+
+	readThrough func(key string) (interface{}, time.Duration, bool) // Set at construction
+	loadMu      sync.Mutex                                          // Guards loads; never held while calling a loader
+	loads       map[string]*call                                    // In-flight loads by key
+
+	refreshPool workPool // Runs SetRefreshing refreshes and OnStale revalidations
+	breaker     *breaker // Guards loaders; nil unless WithCircuitBreaker
+
+	asyncMu     sync.RWMutex    // Held for reading while sending on asyncQ, for writing to close it
+	asyncClosed bool            // Stop has closed asyncQ; guarded by asyncMu
+	asyncOnce   sync.Once       // Creates asyncQ and starts its drainer
+	asyncQ      chan asyncWrite // Pending AsyncSet writes; nil until the first one
+	asyncDone   chan struct{}   // Closed when the drainer exits
+
+	drain func(items []Item) // Called with the live entries by Stop; see WithDrainOnStop
 }
 
 // New creates a new Cache. Starts the background cleanup goroutine with given cleanup interval.
+// A cleanerInterval <= 0 starts no goroutine; see NewManual.
 func New(cleanerInterval time.Duration) *Cache {
+	c := newCache(cleanerInterval)
+	c.start()
+	return c
+}
+
+// NewWithCapacity creates a Cache holding at most maxEntries entries. When a Set
+// of a new key would exceed the limit, the least-recently-used entry is evicted first.
+// A maxEntries <= 0 yields an unbounded cache, same as New.
+func NewWithCapacity(cleanerInterval time.Duration, maxEntries int) *Cache {
+	return NewWithOptions(cleanerInterval, WithCapacity(maxEntries))
+}
+
+// NewWithJitter creates a Cache whose cleanup sweeps are spaced cleanerInterval
+// apart, each delay randomly varied by up to ±jitterFraction of the interval
+// (for example 0.1 for ±10%). This keeps many caches created with the same
+// interval from sweeping in lockstep. jitterFraction is clamped to [0, 1].
+func NewWithJitter(cleanerInterval time.Duration, jitterFraction float64) *Cache {
+	return NewWithOptions(cleanerInterval, WithJitter(jitterFraction))
+}
+
+// NewWithMaxTTL creates a Cache in which no entry lives longer than maxTTL: any
+// TTL above maxTTL, and any TTL <= 0 that would otherwise never expire, is
+// clamped to maxTTL, as is a SetAt deadline further out. This holds for every
+// method that sets or renews a lifetime, including sliding renewals, Touch and
+// Load. A maxTTL <= 0 disables the cap, same as New.
+func NewWithMaxTTL(cleanerInterval, maxTTL time.Duration) *Cache {
+	return NewWithOptions(cleanerInterval, WithMaxTTL(maxTTL))
+}
+
+// NewWithSize creates a Cache whose map is pre-sized for initialCapacity
+// entries, so bulk-loading that many at startup does not rehash repeatedly as
+// the map grows. Flush restores the same pre-sized map. It does not bound the
+// cache; see NewWithCapacity for that.
+func NewWithSize(cleanerInterval time.Duration, initialCapacity int) *Cache {
+	return NewWithOptions(cleanerInterval, WithInitialCapacity(initialCapacity))
+}
+
+// NewWithTTLJitter creates a Cache that randomly varies every positive TTL it
+// is given by up to ±fraction of itself (for example 0.1 for ±10%), so entries
+// written together in a burst with the same TTL expire over a window instead of
+// all at once, spreading both the misses and the cleanup work. A never-expiring
+// TTL stays never-expiring, and a jittered TTL is always positive. fraction is
+// clamped to [0, 1]. Sliding renewals and Touch are jittered too.
+func NewWithTTLJitter(cleanerInterval time.Duration, fraction float64) *Cache {
+	return NewWithOptions(cleanerInterval, WithTTLJitter(fraction))
+}
+
+// NewWithDefaultTTL creates a Cache whose SetDefault stores entries with
+// defaultTTL. Set and the other methods taking a TTL are unaffected and keep
+// using the TTL they are given. A defaultTTL <= 0 makes SetDefault entries never
+// expire.
+func NewWithDefaultTTL(cleanerInterval, defaultTTL time.Duration) *Cache {
+	return NewWithOptions(cleanerInterval, WithDefaultTTL(defaultTTL))
+}
+
+// newCache allocates a Cache without starting the cleanup goroutine, so that
+// constructors can adjust its configuration first.
+func newCache(cleanerInterval time.Duration) *Cache {
 	ctx, cancel := context.WithCancel(context.Background())
-	c := &Cache{
-		data:   make(map[string]entry),
-		ctx:    ctx,
-		cancel: cancel,
+	return &Cache{
+		data:            make(map[string]entry),
+		loads:           make(map[string]*call),
+		tags:            make(map[string]map[string]struct{}),
+		subs:            make(map[*subscriber]struct{}),
+		ctx:             ctx,
+		cancel:          cancel,
 		cleanerInterval: cleanerInterval,
 	}
+}
+
+// NewManual creates a Cache without a background cleanup goroutine. Expired
+// entries are still hidden and lazily removed by Get, and CleanupNow reaps the
+// rest whenever the caller chooses. It is equivalent to New(0).
+func NewManual() *Cache {
+	return New(0)
+}
+
+// start launches the background cleanup goroutine, unless cleanerInterval <= 0
+// asks for manual cleanup only.
+func (c *Cache) start() {
+	if c.cleanerInterval <= 0 {
+		return
+	}
 	c.wg.Add(1)
 	go c.cleanupExpiredEntries()
-	return c
 }
 
 // Set inserts or updates a value in the cache with optional TTL.
 // If ttl <= 0, never expires.
 func (c *Cache) Set(key string, value interface{}, ttl time.Duration) {
-	expiresAt := time.Time{}
-	if ttl > 0 {
-		expiresAt = time.Now().Add(ttl)
+	key = c.normalize(key)
+	expiresAt := c.expiry(c.now(), ttl)
+	c.mu.Lock()
+	defer c.unlock()
+	c.setLocked(key, entry{value: value, expiresAt: expiresAt})
+}
+
+// SetAt stores value with an absolute deadline instead of a TTL, as for entries
+// that must expire at a fixed instant. A zero expiresAt means it never expires;
+// a deadline already in the past stores an entry that reads as expired.
+func (c *Cache) SetAt(key string, value interface{}, expiresAt time.Time) {
+	key = c.normalize(key)
+	expiresAt = c.capDeadline(c.now(), expiresAt)
+	c.mu.Lock()
+	defer c.unlock()
+	c.setLocked(key, entry{value: value, expiresAt: expiresAt})
+}
+
+// SetDefault is Set with the cache-wide default TTL; see NewWithDefaultTTL.
+func (c *Cache) SetDefault(key string, value interface{}) {
+	c.Set(key, value, c.defaultTTL)
+}
+
+// SetIfAbsent stores value only if key has no live entry, reporting whether it did.
+// An expired entry counts as absent and is replaced. The check and the insert
+// happen under one write lock, so exactly one of several racing callers wins.
+func (c *Cache) SetIfAbsent(key string, value interface{}, ttl time.Duration) bool {
+	key = c.normalize(key)
+	now := c.now()
+	c.mu.Lock()
+	defer c.unlock()
+	if e, ok := c.data[key]; ok {
+		if !e.expired(now) {
+			return false
+		}
+		c.removeLocked(key, e, ReasonExpired)
+	}
+	return c.setLocked(key, entry{value: value, expiresAt: c.expiry(now, ttl)})
+}
+
+// LoadOrStore is sync.Map's LoadOrStore with a TTL: if key has a live entry it
+// returns that value with loaded true; otherwise it stores value with ttl (never
+// expiring if ttl <= 0) and returns it with loaded false. Expired and negative
+// entries count as absent and are replaced. The check and the store happen
+// under one write lock, so of several racing callers exactly one stores and the
+// rest load its value. If the store is refused (see Freeze and
+// NewWithMaxValueSize), actual is nil.
+func (c *Cache) LoadOrStore(key string, value interface{}, ttl time.Duration) (actual interface{}, loaded bool) {
+	key = c.normalize(key)
+	now := c.now()
+	c.mu.Lock()
+	defer c.unlock()
+	if e, ok := c.data[key]; ok {
+		if e.expired(now) {
+			c.removeLocked(key, e, ReasonExpired)
+		} else if !e.negative {
+			return c.copyOut(e.value), true
+		}
+	}
+	if !c.setLocked(key, entry{value: value, expiresAt: c.expiry(now, ttl)}) {
+		return nil, false
+	}
+	return value, false
+}
+
+// Replace stores value with a new TTL only if key already has a live entry,
+// reporting whether it did. Missing, expired and negative entries are left alone,
+// so Replace never creates a key. The check and the write happen under one write
+// lock.
+func (c *Cache) Replace(key string, value interface{}, ttl time.Duration) bool {
+	key = c.normalize(key)
+	now := c.now()
+	c.mu.Lock()
+	defer c.unlock()
+	e, ok := c.data[key]
+	if !ok || e.negative {
+		return false
 	}
+	if e.expired(now) {
+		c.removeLocked(key, e, ReasonExpired)
+		return false
+	}
+	return c.setLocked(key, entry{value: value, expiresAt: c.expiry(now, ttl)})
+}
+
+// Touch resets the TTL of a live entry without changing its value: expiresAt
+// becomes now+ttl, or never if ttl <= 0. It reports false if key is missing or
+// already expired, in which case nothing is stored.
+func (c *Cache) Touch(key string, ttl time.Duration) bool {
+	key = c.normalize(key)
+	now := c.now()
 	c.mu.Lock()
-	defer c.mu.Unlock()
-	c.data[key] = entry{
-		value:     value,
-		expiresAt: expiresAt,
+	defer c.unlock()
+	e, ok := c.data[key]
+	if !ok {
+		return false
+	}
+	if e.expired(now) {
+		c.removeLocked(key, e, ReasonExpired)
+		return false
+	}
+	c.setExpiryLocked(key, e, c.expiry(now, ttl))
+	return true
+}
+
+// expiry returns the deadline for an entry stored at now with the given TTL,
+// jittered when TTL jitter is configured and then clamped to the cache's
+// maximum TTL; see deadline.
+func (c *Cache) expiry(now time.Time, ttl time.Duration) time.Time {
+	if c.ttlJitter > 0 && ttl > 0 {
+		ttl = jittered(ttl, c.ttlJitter)
+	}
+	if c.maxTTL > 0 && (ttl <= 0 || ttl > c.maxTTL) {
+		ttl = c.maxTTL
 	}
+	return deadline(now, ttl)
+}
+
+// capDeadline returns at, or the maximum TTL's deadline from now if at lies
+// beyond it or is zero.
+func (c *Cache) capDeadline(now, at time.Time) time.Time {
+	if c.maxTTL <= 0 {
+		return at
+	}
+	if limit := c.expiry(now, c.maxTTL); at.IsZero() || at.After(limit) {
+		return limit
+	}
+	return at
+}
+
+// deadline returns now+ttl, or the zero time (never expires) for ttl <= 0. A TTL
+// so large that the deadline cannot be represented also means never, rather than
+// wrapping around to a deadline in the past.
+func deadline(now time.Time, ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	if at := now.Add(ttl); at.After(now) {
+		return at
+	}
+	return time.Time{}
+}
+
+// SetSliding inserts or updates a value whose TTL restarts on every successful Get,
+// so the entry lives as long as it keeps being read within ttl of the last access.
+// If ttl <= 0 the entry never expires, as with Set.
+//
+// Renewing a deadline is a write, so a Get that hits a sliding entry briefly takes
+// the write lock after its read-locked lookup. Entries stored with Set stay on the
+// read-lock-only path.
+func (c *Cache) SetSliding(key string, value interface{}, ttl time.Duration) {
+	if ttl <= 0 {
+		c.Set(key, value, 0)
+		return
+	}
+	key = c.normalize(key)
+	c.mu.Lock()
+	defer c.unlock()
+	c.setLocked(key, entry{value: value, expiresAt: c.expiry(c.now(), ttl), sliding: ttl})
 }
 
 // Get retrieves a value. Returns (value, true) if found and not expired, else (nil, false)
+//
+// On a capacity-bounded cache a hit updates the LRU order, so Get takes the
+// write lock there instead of the read lock.
 func (c *Cache) Get(key string) (interface{}, bool) {
+	key = c.normalize(key)
+	e, ok := c.get(key, c.now())
+	if !ok {
+		if c.readThrough != nil {
+			return c.readThroughMiss(key)
+		}
+		return nil, false
+	}
+	if e.negative {
+		return nil, false
+	}
+	return e.value, true
+}
+
+// GetOrDefault returns the value for key, or def if Get would miss. It is Get in
+// every other respect: expired entries are reaped lazily, hits and misses are
+// counted and a read-through loader is consulted. def is not stored.
+func (c *Cache) GetOrDefault(key string, def interface{}) interface{} {
+	if v, ok := c.Get(key); ok {
+		return v
+	}
+	return def
+}
+
+// Peek returns the value for key if it is present and not expired, without any
+// of Get's side effects: it does not update LRU order, renew sliding TTLs,
+// trigger revalidation, lazily delete expired entries or count hits and misses.
+// It only takes the read lock.
+func (c *Cache) Peek(key string) (interface{}, bool) {
+	key = c.normalize(key)
 	c.mu.RLock()
-	entry, ok := c.data[key]
+	defer c.mu.RUnlock()
+	e, ok := c.data[key]
+	if !ok || e.negative || e.expired(c.now()) {
+		return nil, false
+	}
+	return c.copyOut(e.value), true
+}
+
+// Has reports whether key has a live entry without reading its value: no copy is
+// made, and neither recency, sliding TTLs nor hit/miss counters are touched. An
+// expired entry reports false and is reaped on the spot.
+func (c *Cache) Has(key string) bool {
+	key = c.normalize(key)
+	now := c.now()
+	c.mu.RLock()
+	e, ok := c.data[key]
+	c.mu.RUnlock()
+	if !ok || e.negative {
+		return false
+	}
+	if e.expired(now) {
+		c.mu.Lock()
+		if e2, stillOk := c.data[key]; stillOk && e2.expiresAt.Equal(e.expiresAt) {
+			c.removeLocked(key, e2, ReasonExpired)
+		}
+		c.unlock()
+		return false
+	}
+	return true
+}
+
+// GetWithTTL is Get that also reports how long the entry has left to live.
+// The returned ttl is 0 for entries that never expire. Expired keys are handled
+// exactly as in Get.
+func (c *Cache) GetWithTTL(key string) (value interface{}, ttl time.Duration, ok bool) {
+	key = c.normalize(key)
+	now := c.now()
+	e, ok := c.get(key, now)
+	if !ok || e.negative {
+		return nil, 0, false
+	}
+	if !e.expiresAt.IsZero() {
+		ttl = e.expiresAt.Sub(now)
+	}
+	return e.value, ttl, true
+}
+
+// get is the lookup shared by Get and its variants. It counts the hit or miss,
+// lazily removes an expired entry, renews sliding deadlines and LRU order, and
+// returns the entry as it stands after renewal, with its value copied in copy mode.
+func (c *Cache) get(key string, now time.Time) (entry, bool) {
+	e, state := c.getState(key, now)
+	return e, state == StateHit
+}
+
+// getState is get, also telling a miss on an absent key from one on an
+// expired entry.
+func (c *Cache) getState(key string, now time.Time) (entry, EntryState) {
+	e, state := c.getEntry(key, now)
+	if c.sampleEvery > 0 && c.sampleReads.Add(1)%c.sampleEvery == 0 {
+		c.sampleExpired(now)
+	}
+	if state == StateHit {
+		e.accessed.Store(now.UnixNano())
+		e.value = c.copyOut(e.value)
+	}
+	return e, state
+}
+
+// getEntry implements getState, returning the stored value itself.
+func (c *Cache) getEntry(key string, now time.Time) (entry, EntryState) {
+	if c.tracked.Load() {
+		return c.getTracked(key, now)
+	}
+
+	c.mu.RLock()
+	e, ok := c.data[key]
+	revalidate := c.revalidator != nil
 	c.mu.RUnlock()
 
 	if !ok {
-		return nil, false
+		c.misses.Add(1)
+		return entry{}, StateMiss
 	}
-	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+	if e.expired(now) {
 		// Key expired, remove it
+		c.misses.Add(1)
 		c.mu.Lock()
 		// Double-check expiry and existence
-		entry2, stillOk := c.data[key]
-		if stillOk && entry2.expiresAt.Equal(entry.expiresAt) {
-			delete(c.data, key)
+		e2, stillOk := c.data[key]
+		if stillOk && e2.expiresAt.Equal(e.expiresAt) {
+			c.removeLocked(key, e2, ReasonExpired)
+		}
+		c.unlock()
+		return entry{}, StateExpired
+	}
+	if e.sliding > 0 {
+		c.mu.Lock()
+		// Renew only if nobody replaced or renewed the entry in the meantime.
+		if e2, stillOk := c.data[key]; stillOk && e2.expiresAt.Equal(e.expiresAt) {
+			e = c.setExpiryLocked(key, e2, c.expiry(now, e2.sliding))
 		}
 		c.mu.Unlock()
-		return nil, false
 	}
-	return entry.value, true
+	if revalidate && e.stale(now) && !e.refreshing {
+		c.mu.Lock()
+		if e2, stillOk := c.data[key]; stillOk && e2.staleAt.Equal(e.staleAt) {
+			c.revalidateLocked(key, e2)
+		}
+		c.mu.Unlock()
+	}
+	c.countFound(e)
+	return e, StateHit
+}
+
+// countFound counts a lookup that found the live entry e as a hit, or as a miss
+// if e is negative, since readers see negative entries as absent.
+func (c *Cache) countFound(e entry) {
+	if e.negative {
+		c.misses.Add(1)
+		return
+	}
+	c.hits.Add(1)
+}
+
+// getTracked is get for capacity-bounded caches: it marks a hit as most recently used.
+func (c *Cache) getTracked(key string, now time.Time) (entry, EntryState) {
+	c.mu.Lock()
+	defer c.unlock()
+	return c.getLocked(key, now)
+}
+
+// getLocked performs get's bookkeeping with c.mu already held for writing.
+func (c *Cache) getLocked(key string, now time.Time) (entry, EntryState) {
+	e, ok := c.data[key]
+	if !ok {
+		c.misses.Add(1)
+		return entry{}, StateMiss
+	}
+	if e.expired(now) {
+		c.removeLocked(key, e, ReasonExpired)
+		c.misses.Add(1)
+		return entry{}, StateExpired
+	}
+	if e.sliding > 0 {
+		e = c.setExpiryLocked(key, e, c.expiry(now, e.sliding))
+	}
+	if e.stale(now) {
+		c.revalidateLocked(key, e)
+	}
+	if e.elem != nil {
+		c.lru.MoveToFront(e.elem)
+	}
+	if e.welem != nil {
+		c.weighted[e.weight].MoveToFront(e.welem)
+	}
+	if c.policy != nil {
+		c.policy.OnAccess(key)
+	}
+	c.countFound(e)
+	return e, StateHit
 }
 
 // Delete removes a key from the cache
 func (c *Cache) Delete(key string) {
+	key = c.normalize(key)
+	c.mu.Lock()
+	defer c.unlock()
+	if e, ok := c.data[key]; ok && !c.frozen.Load() {
+		c.removeLocked(key, e, ReasonDeleted)
+	}
+}
+
+// GetAndDelete removes key and returns the value it held, under one write lock,
+// so that of several racing callers exactly one receives the value. An expired
+// entry is reaped and reported as missing, as is a negative entry, which is
+// left in place. OnEvict sees the removal as ReasonDeleted.
+func (c *Cache) GetAndDelete(key string) (interface{}, bool) {
+	key = c.normalize(key)
+	now := c.now()
+	c.mu.Lock()
+	defer c.unlock()
+	e, ok := c.data[key]
+	if !ok || e.negative || c.frozen.Load() {
+		return nil, false
+	}
+	if e.expired(now) {
+		c.removeLocked(key, e, ReasonExpired)
+		return nil, false
+	}
+	c.removeLocked(key, e, ReasonDeleted)
+	return c.copyOut(e.value), true
+}
+
+// Flush removes every entry at once by swapping in a fresh map, which lets the
+// old one be garbage collected. OnEvict callbacks fire with ReasonFlush for each
+// removed entry. The cleanup goroutine keeps running and simply sees an empty map.
+func (c *Cache) Flush() {
+	c.mu.Lock()
+	defer c.unlock()
+	c.flushLocked()
+}
+
+// flushLocked implements Flush. c.mu must be held for writing.
+func (c *Cache) flushLocked() {
+	if c.frozen.Load() {
+		return
+	}
+	if len(c.onEvict)+len(c.onBatch) > 0 || c.subscribers.Load() > 0 || c.policy != nil {
+		for k, e := range c.data {
+			if c.policy != nil {
+				c.policy.OnRemove(k)
+			}
+			c.notifyRemovalLocked(k, e, ReasonFlush)
+		}
+	}
+	c.data = make(map[string]entry, c.sizeHint)
+	c.bytes.Store(0)
+	c.tags = make(map[string]map[string]struct{})
+	c.dependents = nil
+	c.expiries = nil
+	if c.lru != nil {
+		c.lru.Init()
+	}
+	if c.order != nil {
+		c.order.Init()
+	}
+	c.weighted = nil
+}
+
+// Compact rebuilds the cache's map into a freshly sized one holding only the
+// live entries, so that memory held by a map that grew large and was then
+// mostly emptied by Delete, DeletePrefix, InvalidateTag or expiry can be
+// reclaimed; Go maps never shrink on their own. Expired entries are first
+// reaped by the same sweep as DeleteExpired, with its ForEachExpired hook and
+// cleanup stats, and the expiry heap is rebuilt too.
+//
+// Compact is O(n) and holds the write lock throughout, blocking every other
+// cache user for the duration. It is meant to be called once after a large
+// invalidation, not periodically. Flush needs no compaction: it already swaps
+// in a fresh map.
+func (c *Cache) Compact() {
+	c.sweep(c.now())
 	c.mu.Lock()
-	defer c.mu.Unlock()
+	defer c.unlock()
+	data := make(map[string]entry, max(len(c.data), c.sizeHint))
+	for k, e := range c.data {
+		data[k] = e
+	}
+	c.data = data
+	c.compactExpiriesLocked()
+}
+
+// Len returns the number of live entries. Entries whose TTL has passed but that
+// the cleaner has not reaped yet are not counted, nor are negative entries, so
+// Len agrees with Get.
+// It scans the whole map under the read lock and is therefore O(n).
+func (c *Cache) Len() int {
+	now := c.now()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	n := 0
+	for _, e := range c.data {
+		if !e.negative && !e.expired(now) {
+			n++
+		}
+	}
+	return n
+}
+
+// setLocked stores e under key, replacing any previous entry, and evicts
+// least-recently-used entries while the write would exceed the entry or byte
+// limit. Bookkeeping fields of e are managed here; a zero createdAt is read from
+// the clock, so callers storing several entries pass the instant they captured
+// to keep one consistent time across the batch. It reports false, and stores
+// nothing, if the cache is frozen or the value exceeds the maximum value size.
+// c.mu must be held for writing.
+func (c *Cache) setLocked(key string, e entry) bool {
+	if c.frozen.Load() {
+		return false
+	}
+	e.value = c.copyIn(e.value)
+	return c.storeLocked(key, e)
+}
+
+// storeLocked is setLocked for a value that is already the cache's own copy,
+// taken with copyIn before the lock. c.mu must be held for writing.
+func (c *Cache) storeLocked(key string, e entry) bool {
+	if c.frozen.Load() {
+		return false
+	}
+	old, exists := c.data[key]
+	if e.createdAt.IsZero() {
+		e.createdAt = c.now()
+	}
+	if e.accessed == nil {
+		e.accessed = new(atomic.Int64)
+	}
+	e.size = c.sizeOf(e.value)
+	if c.maxValueSize > 0 && !e.negative && e.size > c.maxValueSize {
+		return false
+	}
+	if exists && old.elem != nil {
+		// Move the key out of the eviction path before making room.
+		c.lru.MoveToFront(old.elem)
+	}
+	if exists && c.policy != nil {
+		c.policy.OnAccess(key)
+	}
+	if c.makeRoomLocked(key, e.size) {
+		// An eviction cascaded into key's old entry and removed it.
+		old, exists = entry{}, false
+	}
+	if !exists && c.policy != nil {
+		c.policy.OnAdd(key)
+	}
+	if c.lru != nil {
+		if exists {
+			e.elem = old.elem
+		} else {
+			e.elem = c.lru.PushFront(key)
+		}
+		e.welem = c.linkWeightLocked(key, old, e.weight)
+	}
+	if c.order != nil {
+		if exists {
+			e.oelem = old.oelem
+		} else {
+			e.oelem = c.order.PushBack(key)
+		}
+	}
+	c.generation++
+	e.version = c.generation
+	c.data[key] = e
+	c.bytes.Add(e.size - old.size)
+	if n := int64(len(c.data)); n > c.peakEntries.Load() {
+		c.peakEntries.Store(n)
+	}
+	if b := c.bytes.Load(); b > c.peakBytes.Load() {
+		c.peakBytes.Store(b)
+	}
+	if c.subscribers.Load() > 0 {
+		c.events = append(c.events, Event{Key: key, Op: OpSet, Value: c.copyOut(e.value)})
+	}
+	if old.refresh != e.refresh {
+		old.refresh.cancel()
+	}
+	c.untagLocked(key, old.tags)
+	c.tagLocked(key, e.tags)
+	c.undependLocked(key, old.parents)
+	c.dependLocked(key, e.parents)
+	if !exists || !old.expiresAt.Equal(e.expiresAt) {
+		c.scheduleLocked(key, e.expiresAt)
+	}
+	c.invalidateDependentsLocked(key)
+	return true
+}
+
+// makeRoomLocked evicts entries until storing key, with a value of the given
+// size, fits the configured limits. key itself is never chosen as a victim, but
+// removing a victim can cascade into key's stored entry through its
+// dependencies; makeRoomLocked reports whether that happened, leaving key absent.
+// c.mu must be held for writing.
+func (c *Cache) makeRoomLocked(key string, size int64) (removed bool) {
+	old, exists := c.data[key]
+	for {
+		overCount := !exists && c.maxEntries > 0 && len(c.data) >= c.maxEntries
+		overBytes := c.maxBytes > 0 && c.bytes.Load()+size-old.size > c.maxBytes
+		if !overCount && !overBytes {
+			return removed
+		}
+		if !c.evictLocked(key, true) {
+			return removed
+		}
+		if exists {
+			if old, exists = c.data[key]; !exists {
+				removed = true
+			}
+		}
+	}
+}
+
+// setExpiryLocked changes the deadline of the stored entry e and returns the
+// updated entry. c.mu must be held for writing.
+func (c *Cache) setExpiryLocked(key string, e entry, expiresAt time.Time) entry {
+	e.expiresAt = expiresAt
+	c.data[key] = e
+	c.scheduleLocked(key, expiresAt)
+	return e
+}
+
+// removeLocked deletes key, unlinks it from the LRU list, updates stats for reason
+// and queues eviction callbacks. c.mu must be held for writing and released with unlock.
+func (c *Cache) removeLocked(key string, e entry, reason EvictReason) {
+	if e.elem != nil {
+		c.lru.Remove(e.elem)
+	}
+	if e.welem != nil {
+		c.unlinkWeightLocked(e)
+	}
+	if e.oelem != nil {
+		c.order.Remove(e.oelem)
+	}
+	if c.policy != nil {
+		c.policy.OnRemove(key)
+	}
 	delete(c.data, key)
+	c.bytes.Add(-e.size)
+	e.refresh.cancel()
+	c.untagLocked(key, e.tags)
+	c.undependLocked(key, e.parents)
+	switch reason {
+	case ReasonExpired:
+		c.expirations.Add(1)
+	case ReasonCapacity:
+		c.evictions.Add(1)
+	}
+	c.notifyRemovalLocked(key, e, reason)
+	c.invalidateDependentsLocked(key)
 }
 
-// Stop stops the background cleanup goroutine and waits for completion.
+// notifyRemovalLocked queues the OnEvict callbacks and subscriber event for a
+// removal. c.mu must be held for writing and released with unlock.
+func (c *Cache) notifyRemovalLocked(key string, e entry, reason EvictReason) {
+	if len(c.onEvict)+len(c.onBatch) > 0 {
+		c.pending = append(c.pending, evicted{key: key, value: e.value, expiresAt: e.expiresAt, negative: e.negative, reason: reason})
+	}
+	if c.subscribers.Load() > 0 {
+		op := OpDelete
+		if reason == ReasonExpired {
+			op = OpExpire
+		}
+		c.events = append(c.events, Event{Key: key, Op: op})
+	}
+}
+
+// unlock releases the write lock and then publishes the events and runs the
+// eviction callbacks queued while it was held, so callbacks may safely call back
+// into the cache. subMu is taken before mu is released so that events from
+// successive critical sections are published in the order they happened.
+func (c *Cache) unlock() {
+	pending, callbacks, batched, events := c.pending, c.onEvict, c.onBatch, c.events
+	c.pending, c.events = nil, nil
+	if len(events) > 0 {
+		c.subMu.Lock()
+		c.mu.Unlock()
+		c.publish(events)
+		c.subMu.Unlock()
+	} else {
+		c.mu.Unlock()
+	}
+	for _, ev := range pending {
+		for _, fn := range callbacks {
+			fn(ev)
+		}
+	}
+	if len(pending) > 0 && len(batched) > 0 {
+		items := make([]EvictedItem, len(pending))
+		for i, ev := range pending {
+			items[i] = EvictedItem{Key: ev.key, Value: ev.value, ExpiresAt: ev.expiresAt, Reason: ev.reason}
+		}
+		for _, fn := range batched {
+			fn(items)
+		}
+	}
+}
+
+// evictLocked removes the entry capacity eviction picks next, never keep if
+// protect is set, and reports whether it evicted anything: the
+// least-recently-used entry among those with the lowest weight. c.mu must be
+// held for writing.
+func (c *Cache) evictLocked(keep string, protect bool) bool {
+	key, ok := c.victimLocked(keep, protect)
+	if !ok {
+		return false
+	}
+	c.removeLocked(key, c.data[key], ReasonCapacity)
+	return true
+}
+
+// Stop stops the background cleanup goroutine and waits for completion. Only the
+// first call does anything; later and concurrent calls wait for it to finish and
+// return. The cache stays usable after Stop: reads and writes work as before and
+// expired entries are still hidden and lazily removed, but nothing sweeps them in
+// the background and SetRefreshing no longer schedules refreshes. Writes queued
+// by AsyncSet are applied, and the drain set by WithDrainOnStop runs, before
+// Stop returns.
 func (c *Cache) Stop() {
-	c.cancel()
-	c.wg.Wait()
+	c.stopOnce.Do(func() {
+		c.cancel()
+		c.wg.Wait()
+		c.stopRefreshes()
+		c.stopAsync()
+		if c.drain != nil {
+			c.drain(c.liveItems())
+		}
+	})
 }
 
 // cleanupExpiredEntries periodically scans for expired entries and deletes them.
 func (c *Cache) cleanupExpiredEntries() {
-	ticker := time.NewTicker(c.cleanerInterval)
+	defer c.wg.Done()
+	runCleanup(c.ctx, c.nextCleanup, c.backgroundSweep)
+}
+
+// nextCleanup returns the delay before the next sweep: cleanerInterval, or the
+// adaptive interval when WithAdaptiveCleanup is configured, jittered by up to
+// ±jitter of itself when jitter is configured. It is always positive.
+func (c *Cache) nextCleanup() time.Duration {
+	base := c.cleanerInterval
+	if c.maxInterval > 0 {
+		base = c.adaptiveInterval()
+	}
+	if c.jitter <= 0 {
+		return base
+	}
+	return jittered(base, c.jitter)
+}
+
+// jittered returns d varied uniformly by up to ±fraction of itself, never less
+// than 1ns and never more than the largest Duration, so that a huge d cannot
+// wrap around to a short one.
+func jittered(d time.Duration, fraction float64) time.Duration {
+	j := float64(d) * (1 + fraction*(2*rand.Float64()-1))
+	if j >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+	if j < 1 {
+		return 1
+	}
+	return time.Duration(j)
+}
+
+// DeleteExpired synchronously removes every expired entry and returns how many
+// it removed; each removal also counts towards Stats().Expirations, as do
+// expired entries reaped lazily by reads. It runs the same sweep as the
+// background goroutine and is safe to call whether or not that goroutine is
+// running. With a cleanup batch size configured, the lock is released between
+// batches.
+func (c *Cache) DeleteExpired() int {
+	return c.sweep(c.now())
+}
+
+// CleanupNow is DeleteExpired under its original name.
+func (c *Cache) CleanupNow() int {
+	return c.DeleteExpired()
+}
+
+// sweep deletes every expired entry and returns the number removed, holding the
+// write lock for at most cleanupBatch heap nodes at a time.
+func (c *Cache) sweep(now time.Time) int {
+	start := time.Now()
+	scanned, removed := 0, 0
+	for {
+		s, r, done := c.sweepChunk(now, c.cleanupBatch)
+		scanned += s
+		removed += r
+		if done {
+			break
+		}
+		runtime.Gosched()
+	}
+	c.recordSweep(start, scanned, removed)
+	return removed
+}
+
+// backgroundSweep is the cleanup goroutine's sweep: DeleteExpired, unless a batch
+// size is configured, in which case it processes a single batch per tick and
+// leaves any backlog for later ticks; until then, expired entries stay invisible
+// to readers through lazy expiry.
+// The tick's time is ignored in favour of the cache's clock.
+func (c *Cache) backgroundSweep(time.Time) int {
+	if c.paused.Load() {
+		return 0
+	}
+	if c.cleanupBatch <= 0 {
+		removed := c.DeleteExpired()
+		c.adapt(removed)
+		return removed
+	}
+	start := time.Now()
+	scanned, removed, _ := c.sweepChunk(c.now(), c.cleanupBatch)
+	c.recordSweep(start, scanned, removed)
+	c.adapt(removed)
+	return removed
+}
+
+// PauseCleanup suspends background sweeps, for instance during a bulk import
+// that should not compete with them for the write lock. The cleanup goroutine
+// keeps running and skips its ticks until ResumeCleanup. Expired entries stay
+// hidden from reads and are still reaped lazily, and CleanupNow still sweeps on
+// demand. PauseCleanup and ResumeCleanup are idempotent and safe to call from
+// any goroutine.
+func (c *Cache) PauseCleanup() {
+	c.paused.Store(true)
+}
+
+// ResumeCleanup resumes background sweeps from the next tick; see PauseCleanup.
+func (c *Cache) ResumeCleanup() {
+	c.paused.Store(false)
+}
+
+// recordSweep publishes the cost of a sweep that began at start to Stats and
+// the logger.
+func (c *Cache) recordSweep(start time.Time, scanned, removed int) {
+	end := time.Now()
+	c.logSweep(end.Sub(start), scanned, removed)
+	c.lastCleanup.Store(int64(end.Sub(start)))
+	c.lastCleanupAt.Store(end.UnixNano())
+	c.lastScanned.Store(int64(scanned))
+	c.lastRemoved.Store(int64(removed))
+}
+
+// sweepChunk removes expired entries under a single write-lock acquisition,
+// examining at most limit heap nodes (all of them if limit <= 0). It pops
+// deadlines off the expiry heap and stops at the first one still in the future,
+// so its cost depends on how many entries expired rather than on the size of the
+// cache. scanned counts the nodes popped, including stale ones; done reports
+// whether no expired deadline remains. The ForEachExpired hook runs for the
+// removed entries after the lock is released and the OnEvict callbacks have run.
+func (c *Cache) sweepChunk(now time.Time, limit int) (scanned, removed int, done bool) {
+	c.mu.Lock()
+	hook := c.onSwept
+	var reaped []evicted
 	defer func() {
-		ticker.Stop()
-		c.wg.Done()
+		for _, ev := range reaped {
+			hook(ev.key, ev.value)
+		}
 	}()
+	defer c.unlock()
+	for ; len(c.expiries) > 0 && now.After(c.expiries[0].at); scanned++ {
+		if limit > 0 && scanned == limit {
+			return scanned, removed, false
+		}
+		n := heap.Pop(&c.expiries).(expiryNode)
+		// Nodes left behind by Delete, overwrites and renewals no longer match.
+		if e, ok := c.data[n.key]; ok && e.expiresAt.Equal(n.at) {
+			c.removeLocked(n.key, e, ReasonExpired)
+			removed++
+			if hook != nil {
+				reaped = append(reaped, evicted{key: n.key, value: e.value})
+			}
+		}
+	}
+	return scanned, removed, true
+}
+
+// runCleanup calls sweep after each delay returned by next until ctx is
+// cancelled. It is shared by Cache and TypedCache.
+func runCleanup(ctx context.Context, next func() time.Duration, sweep func(now time.Time) int) {
+	timer := time.NewTimer(next())
+	defer timer.Stop()
 	for {
 		select {
-		case <-c.ctx.Done():
+		case <-ctx.Done():
 			return
-		case <-ticker.C:
-			now := time.Now()
-			c.mu.Lock()
-			for k, e := range c.data {
-				if !e.expiresAt.IsZero() && now.After(e.expiresAt) {
-					delete(c.data, k)
-				}
-			}
-			c.mu.Unlock()
+		case now := <-timer.C:
+			sweep(now)
+			timer.Reset(next())
 		}
 	}
 }
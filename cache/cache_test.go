@@ -1,13 +1,16 @@
 package cache
 
 import (
-	"context"
+	"math"
 	"math/rand"
 	"runtime"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/UtkrushtApps/go-concurrent-cache-answers/cache/cachetest"
 )
 
 func TestCacheBasicOperations(t *testing.T) {
@@ -117,6 +120,7 @@ func TestConcurrentReadersWriters(t *testing.T) {
 	done.Wait()
 
 	// Some keys should have been expired after some time
+	time.Sleep(200 * time.Millisecond)
 	surviving := 0
 	for i := 0; i < keyCount; i++ {
 		_, ok := c.Get("k" + string(rune(i)))
@@ -140,7 +144,7 @@ func TestBackgroundCleanupShutdown(t *testing.T) {
 	// Allow Go scheduler to release any goroutine
 	time.Sleep(10 * time.Millisecond)
 	endG := runtime.NumGoroutine()
-	if endG - startG > 2 { // Give buffer for other goroutines
+	if endG-startG > 2 { // Give buffer for other goroutines
 		t.Fatalf("possible goroutine leak: delta=%d", endG-startG)
 	}
 	// All expired should be gone
@@ -149,6 +153,36 @@ func TestBackgroundCleanupShutdown(t *testing.T) {
 	}
 }
 
+func TestStopIsIdempotent(t *testing.T) {
+	c := New(time.Millisecond)
+	c.Set("k", 1, 0)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.Stop()
+		}()
+	}
+	wg.Wait()
+	c.Stop()
+
+	// The cache keeps serving after Stop, with lazy expiry only.
+	if v, ok := c.Get("k"); !ok || v != 1 {
+		t.Fatalf("expected reads to work after Stop, got %v ok=%v", v, ok)
+	}
+	c.Set("exp", 1, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	if _, ok := c.Get("exp"); ok {
+		t.Fatalf("expected expired entries to stay hidden after Stop")
+	}
+
+	tc := NewTyped[string, int](time.Millisecond)
+	tc.Stop()
+	tc.Stop()
+}
+
 func TestGetRemovesExpiredKey(t *testing.T) {
 	c := New(20 * time.Millisecond)
 	defer c.Stop()
@@ -159,3 +193,768 @@ func TestGetRemovesExpiredKey(t *testing.T) {
 		t.Fatalf("Get should remove and return nil/false for expired entry")
 	}
 }
+
+func TestCapacityEvictsLeastRecentlyUsed(t *testing.T) {
+	c := NewWithCapacity(time.Second, 2)
+	defer c.Stop()
+
+	c.Set("a", 1, 0)
+	c.Set("b", 2, 0)
+	c.Get("a") // "b" is now the least recently used
+	c.Set("c", 3, 0)
+
+	if _, ok := c.Get("b"); ok {
+		t.Fatalf("expected b to be evicted")
+	}
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Fatalf("expected a to survive, got %v ok=%v", v, ok)
+	}
+	if v, ok := c.Get("c"); !ok || v != 3 {
+		t.Fatalf("expected c to be stored, got %v ok=%v", v, ok)
+	}
+	if c.lru.Len() != len(c.data) {
+		t.Fatalf("lru list out of sync: list=%d map=%d", c.lru.Len(), len(c.data))
+	}
+}
+
+func TestCapacityDeleteUnlinks(t *testing.T) {
+	c := NewWithCapacity(time.Second, 2)
+	defer c.Stop()
+
+	c.Set("a", 1, 0)
+	c.Set("b", 2, 0)
+	c.Delete("a")
+	c.Set("c", 3, 0) // fits without evicting b
+	if _, ok := c.Get("b"); !ok {
+		t.Fatalf("expected b to survive after delete freed a slot")
+	}
+	if c.lru.Len() != 2 {
+		t.Fatalf("expected 2 list nodes, got %d", c.lru.Len())
+	}
+}
+
+func TestUnboundedCapacity(t *testing.T) {
+	c := NewWithCapacity(time.Second, 0)
+	defer c.Stop()
+	for i := 0; i < 100; i++ {
+		c.Set(string(rune('a'+i)), i, 0)
+	}
+	if len(c.data) != 100 {
+		t.Fatalf("expected unbounded cache to keep 100 entries, got %d", len(c.data))
+	}
+}
+
+func TestLenExcludesExpired(t *testing.T) {
+	c := New(time.Hour)
+	defer c.Stop()
+
+	c.Set("a", 1, 0)
+	c.Set("b", 2, time.Minute)
+	c.Set("c", 3, 5*time.Millisecond)
+	if n := c.Len(); n != 3 {
+		t.Fatalf("expected 3 entries, got %d", n)
+	}
+	time.Sleep(15 * time.Millisecond)
+	if n := c.Len(); n != 2 {
+		t.Fatalf("expected expired entry to be excluded, got %d", n)
+	}
+	c.Delete("a")
+	if n := c.Len(); n != 1 {
+		t.Fatalf("expected 1 entry after delete, got %d", n)
+	}
+}
+
+func TestSlidingExpirationRenewsOnGet(t *testing.T) {
+	for _, capacity := range []int{0, 10} {
+		c := NewWithCapacity(10*time.Millisecond, capacity)
+
+		c.SetSliding("s", "session", 40*time.Millisecond)
+		c.Set("f", "fixed", 40*time.Millisecond)
+		for i := 0; i < 4; i++ {
+			time.Sleep(20 * time.Millisecond)
+			if _, ok := c.Get("s"); !ok {
+				t.Fatalf("capacity=%d: sliding entry expired while in use (iteration %d)", capacity, i)
+			}
+		}
+		if _, ok := c.Get("f"); ok {
+			t.Fatalf("capacity=%d: fixed TTL entry should have expired", capacity)
+		}
+
+		// Left idle, the sliding entry is reaped by the cleaner.
+		time.Sleep(70 * time.Millisecond)
+		c.mu.RLock()
+		_, present := c.data["s"]
+		c.mu.RUnlock()
+		if present {
+			t.Fatalf("capacity=%d: idle sliding entry should have been reaped", capacity)
+		}
+		c.Stop()
+	}
+}
+
+func TestCompactReapsThroughTheSweepAndKeepsLiveEntries(t *testing.T) {
+	clock := cachetest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	c := NewWithOptions(0, WithClock(clock))
+	defer c.Stop()
+
+	const n = 1000
+	for i := 0; i < n; i++ {
+		c.Set(strconv.Itoa(i), i, 0)
+	}
+	c.Set("short", 1, time.Millisecond)
+	c.Set("live", 2, time.Hour)
+	for i := 10; i < n; i++ {
+		c.Delete(strconv.Itoa(i))
+	}
+	var swept []string
+	c.ForEachExpired(func(k string, _ interface{}) { swept = append(swept, k) })
+	clock.Advance(time.Second)
+
+	c.Compact()
+	if len(swept) != 1 || swept[0] != "short" {
+		t.Fatalf("expected the ForEachExpired hook to see the reaped entry, got %v", swept)
+	}
+	if c.Len() != 11 {
+		t.Fatalf("expected the 11 live entries to survive, got %d", c.Len())
+	}
+	for _, k := range []string{"0", "5", "9", "live"} {
+		if !c.Has(k) {
+			t.Fatalf("expected %s to survive compaction", k)
+		}
+	}
+	if v, _ := c.Get("5"); v != 5 {
+		t.Fatalf("expected live values to be kept, got %v", v)
+	}
+	if s := c.Stats(); s.Expirations != 1 || s.LastCleanupRemoved != 1 || s.LastCleanupAt.IsZero() {
+		t.Fatalf("expected Compact's sweep to be recorded, got %+v", s)
+	}
+}
+
+func TestFlushDropsEverything(t *testing.T) {
+	c := NewWithCapacity(10*time.Millisecond, 10)
+	defer c.Stop()
+	log := newEvictLog(c)
+
+	c.Set("a", 1, 0)
+	c.Set("b", 2, time.Minute)
+	c.Flush()
+
+	if n := c.Len(); n != 0 {
+		t.Fatalf("expected empty cache after flush, got %d entries", n)
+	}
+	if c.lru.Len() != 0 {
+		t.Fatalf("expected empty LRU list after flush, got %d", c.lru.Len())
+	}
+	for _, k := range []string{"a", "b"} {
+		if r, ok := log.reason(k); !ok || r != ReasonFlush {
+			t.Fatalf("%s: expected flush callback, got %v fired=%v", k, r, ok)
+		}
+	}
+
+	// The cache remains usable and the cleaner keeps working.
+	c.Set("c", 3, 5*time.Millisecond)
+	time.Sleep(40 * time.Millisecond)
+	if r, ok := log.reason("c"); !ok || r != ReasonExpired {
+		t.Fatalf("expected c to be reaped after flush, got %v fired=%v", r, ok)
+	}
+}
+
+func TestGetWithTTL(t *testing.T) {
+	c := New(time.Hour)
+	defer c.Stop()
+
+	c.Set("ttl", "v", time.Minute)
+	c.Set("forever", "v", 0)
+	c.Set("gone", "v", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	v, ttl, ok := c.GetWithTTL("ttl")
+	if !ok || v != "v" || ttl <= 59*time.Second || ttl > time.Minute {
+		t.Fatalf("expected ~1m remaining, got %v ttl=%v ok=%v", v, ttl, ok)
+	}
+	if _, ttl, ok := c.GetWithTTL("forever"); !ok || ttl != 0 {
+		t.Fatalf("expected zero ttl for non-expiring entry, got %v ok=%v", ttl, ok)
+	}
+	if _, _, ok := c.GetWithTTL("gone"); ok {
+		t.Fatalf("expected expired entry to miss")
+	}
+}
+
+func TestTouchExtendsTTL(t *testing.T) {
+	c := New(time.Hour)
+	defer c.Stop()
+
+	c.Set("k", "v", 20*time.Millisecond)
+	if !c.Touch("k", time.Minute) {
+		t.Fatalf("expected Touch to succeed on live key")
+	}
+	time.Sleep(30 * time.Millisecond)
+	if v, ok := c.Get("k"); !ok || v != "v" {
+		t.Fatalf("expected touched key to survive, got %v ok=%v", v, ok)
+	}
+
+	if !c.Touch("k", 0) {
+		t.Fatalf("expected Touch to succeed")
+	}
+	if _, ttl, _ := c.GetWithTTL("k"); ttl != 0 {
+		t.Fatalf("expected Touch with ttl 0 to clear expiry, got %v", ttl)
+	}
+
+	c.Set("gone", "v", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	if c.Touch("gone", time.Minute) || c.Touch("missing", time.Minute) {
+		t.Fatalf("expected Touch to fail for expired and missing keys")
+	}
+	if _, ok := c.Get("gone"); ok {
+		t.Fatalf("Touch must not resurrect an expired key")
+	}
+}
+
+func TestSetIfAbsentSingleWinner(t *testing.T) {
+	c := New(time.Hour)
+	defer c.Stop()
+
+	var wins int64
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			if c.SetIfAbsent("token", id, time.Minute) {
+				atomic.AddInt64(&wins, 1)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if wins != 1 {
+		t.Fatalf("expected exactly one winner, got %d", wins)
+	}
+
+	c.Set("exp", "old", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	if !c.SetIfAbsent("exp", "new", 0) {
+		t.Fatalf("expected expired key to be treated as absent")
+	}
+	if v, _ := c.Get("exp"); v != "new" {
+		t.Fatalf("expected new value, got %v", v)
+	}
+}
+
+func TestReplaceOnlyUpdatesLiveKeys(t *testing.T) {
+	c := New(time.Hour)
+	defer c.Stop()
+
+	if c.Replace("missing", 1, 0) {
+		t.Fatalf("expected Replace to refuse a missing key")
+	}
+	if _, ok := c.Get("missing"); ok {
+		t.Fatalf("expected Replace not to create the key")
+	}
+
+	c.Set("k", "old", time.Minute)
+	if !c.Replace("k", "new", 0) {
+		t.Fatalf("expected Replace to update a live key")
+	}
+	if v, ttl, _ := c.GetWithTTL("k"); v != "new" || ttl != 0 {
+		t.Fatalf("expected new value without expiry, got %v (ttl %v)", v, ttl)
+	}
+
+	c.Set("exp", "old", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	if c.Replace("exp", "new", 0) {
+		t.Fatalf("expected Replace to refuse an expired key")
+	}
+	if _, ok := c.Get("exp"); ok {
+		t.Fatalf("expected expired key to stay gone")
+	}
+}
+
+func TestHugeTTLNeverExpires(t *testing.T) {
+	c := NewManual()
+	defer c.Stop()
+
+	huge := time.Duration(math.MaxInt64)
+	c.Set("k", 1, huge)
+	c.SetSliding("s", 1, huge)
+	c.SetWithStale("st", 1, huge, huge)
+	time.Sleep(5 * time.Millisecond)
+	c.CleanupNow()
+	for _, k := range []string{"k", "s", "st"} {
+		if _, ok := c.Get(k); !ok {
+			t.Fatalf("expected %s with a MaxInt64 TTL to stay cached", k)
+		}
+	}
+	if d := deadline(time.Now(), huge); !d.IsZero() && !d.After(time.Now()) {
+		t.Fatalf("expected an unrepresentable deadline to mean never, got %v", d)
+	}
+
+	tc := NewTyped[string, int](0)
+	defer tc.Stop()
+	tc.Set("k", 1, huge)
+	if _, ok := tc.Get("k"); !ok {
+		t.Fatalf("expected the typed cache to keep a MaxInt64 TTL entry")
+	}
+}
+
+func TestGetAndDeleteSingleTaker(t *testing.T) {
+	c := New(time.Hour)
+	defer c.Stop()
+
+	c.Set("job", "result", 0)
+	var takers int64
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, ok := c.GetAndDelete("job"); ok && v == "result" {
+				atomic.AddInt64(&takers, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	if takers != 1 || c.Has("job") {
+		t.Fatalf("expected exactly one taker and the key gone, got %d", takers)
+	}
+
+	c.Set("exp", 1, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	if _, ok := c.GetAndDelete("exp"); ok {
+		t.Fatalf("expected an expired key to be missing")
+	}
+	c.mu.RLock()
+	_, present := c.data["exp"]
+	c.mu.RUnlock()
+	if present {
+		t.Fatalf("expected the expired key to be reaped")
+	}
+}
+
+func TestPauseCleanupSkipsBackgroundSweeps(t *testing.T) {
+	c := New(5 * time.Millisecond)
+	defer c.Stop()
+
+	c.PauseCleanup()
+	c.PauseCleanup()
+	c.Set("k", 1, time.Millisecond)
+	time.Sleep(30 * time.Millisecond)
+	c.mu.RLock()
+	_, present := c.data["k"]
+	c.mu.RUnlock()
+	if !present {
+		t.Fatalf("expected no background sweep while paused")
+	}
+	if _, ok := c.Get("k"); ok {
+		t.Fatalf("expected lazy expiry to keep working while paused")
+	}
+
+	c.Set("k2", 1, time.Millisecond)
+	c.ResumeCleanup()
+	c.ResumeCleanup()
+	time.Sleep(5 * time.Millisecond)
+	for i := 0; i < 100 && c.Stats().Expirations < 2; i++ {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if s := c.Stats(); s.Expirations != 2 {
+		t.Fatalf("expected the resumed cleaner to reap k2, got %+v", s)
+	}
+}
+
+func TestSetAtUsesAbsoluteDeadline(t *testing.T) {
+	c := New(time.Hour)
+	defer c.Stop()
+
+	at := time.Now().Add(20 * time.Millisecond)
+	c.SetAt("k", 1, at)
+	c.mu.RLock()
+	stored := c.data["k"].expiresAt
+	c.mu.RUnlock()
+	if !stored.Equal(at) {
+		t.Fatalf("expected the deadline to be stored as given, got %v want %v", stored, at)
+	}
+	time.Sleep(time.Until(at) + 5*time.Millisecond)
+	if _, ok := c.Get("k"); ok {
+		t.Fatalf("expected the entry to expire at its deadline")
+	}
+
+	c.SetAt("forever", 1, time.Time{})
+	if _, ttl, ok := c.GetWithTTL("forever"); !ok || ttl != 0 {
+		t.Fatalf("expected a zero deadline to mean no expiry, got %v ok=%v", ttl, ok)
+	}
+	c.SetAt("past", 1, time.Now().Add(-time.Second))
+	if c.Has("past") {
+		t.Fatalf("expected a past deadline to read as expired")
+	}
+}
+
+func TestSetDefaultUsesDefaultTTL(t *testing.T) {
+	c := NewWithDefaultTTL(time.Hour, time.Minute)
+	defer c.Stop()
+
+	c.SetDefault("d", 1)
+	if _, ttl, _ := c.GetWithTTL("d"); ttl <= 0 || ttl > time.Minute {
+		t.Fatalf("expected the default TTL, got %v", ttl)
+	}
+	c.Set("explicit", 2, 0)
+	if _, ttl, _ := c.GetWithTTL("explicit"); ttl != 0 {
+		t.Fatalf("expected an explicit TTL to override the default, got %v", ttl)
+	}
+
+	plain := New(time.Hour)
+	defer plain.Stop()
+	plain.SetDefault("d", 1)
+	if _, ttl, ok := plain.GetWithTTL("d"); !ok || ttl != 0 {
+		t.Fatalf("expected no default to mean no expiry, got %v", ttl)
+	}
+}
+
+func TestHasChecksPresenceOnly(t *testing.T) {
+	c := NewWithCapacity(time.Hour, 2)
+	defer c.Stop()
+
+	c.Set("a", 1, 0)
+	c.Set("b", 2, 0)
+	if !c.Has("a") || c.Has("missing") {
+		t.Fatalf("expected Has to report only present keys")
+	}
+	// Has must not refresh recency, so "a" is still the eviction victim.
+	c.Set("c", 3, 0)
+	if c.Has("a") {
+		t.Fatalf("expected Has not to promote the key")
+	}
+	if s := c.Stats(); s.Hits != 0 || s.Misses != 0 {
+		t.Fatalf("expected Has not to count hits or misses, got %+v", s)
+	}
+
+	c.Set("exp", 1, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	if c.Has("exp") {
+		t.Fatalf("expected an expired key to be absent")
+	}
+	c.mu.RLock()
+	_, present := c.data["exp"]
+	c.mu.RUnlock()
+	if present {
+		t.Fatalf("expected Has to reap the expired key")
+	}
+}
+
+func TestJitteredCleanupInterval(t *testing.T) {
+	c := NewWithJitter(10*time.Millisecond, 0.5)
+	defer c.Stop()
+
+	varied := false
+	for i := 0; i < 100; i++ {
+		d := c.nextCleanup()
+		if d < 5*time.Millisecond || d > 15*time.Millisecond {
+			t.Fatalf("delay %v outside ±50%% of 10ms", d)
+		}
+		if d != 10*time.Millisecond {
+			varied = true
+		}
+	}
+	if !varied {
+		t.Fatalf("expected jitter to vary the delay")
+	}
+
+	full := NewWithOptions(0, WithJitter(5))
+	defer full.Stop()
+	if full.jitter != 1 {
+		t.Fatalf("expected the jitter fraction to be clamped to 1, got %v", full.jitter)
+	}
+	for i := 0; i < 100; i++ {
+		// Full jitter on a tiny interval may round to zero.
+		if d := jittered(time.Nanosecond, full.jitter); d <= 0 {
+			t.Fatalf("delay must stay positive, got %v", d)
+		}
+	}
+
+	c.Set("k", 1, time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	c.mu.RLock()
+	_, present := c.data["k"]
+	c.mu.RUnlock()
+	if present {
+		t.Fatalf("expected jittered cleaner to reap the entry")
+	}
+}
+
+func TestPeekHasNoSideEffects(t *testing.T) {
+	c := NewWithCapacity(time.Hour, 2)
+	defer c.Stop()
+
+	c.Set("a", 1, 0)
+	c.Set("b", 2, 0)
+	if v, ok := c.Peek("a"); !ok || v != 1 {
+		t.Fatalf("expected to peek a, got %v ok=%v", v, ok)
+	}
+	c.Set("c", 3, 0) // a is still least recently used despite the peek
+	if _, ok := c.Peek("a"); ok {
+		t.Fatalf("Peek must not refresh LRU order")
+	}
+
+	c.SetSliding("s", 4, 20*time.Millisecond)
+	c.Set("gone", 5, time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	c.Peek("s")
+	time.Sleep(15 * time.Millisecond)
+	if _, ok := c.Peek("s"); ok {
+		t.Fatalf("Peek must not renew sliding TTL")
+	}
+	if _, ok := c.Peek("gone"); ok {
+		t.Fatalf("Peek must respect expiry")
+	}
+	c.mu.RLock()
+	_, present := c.data["gone"]
+	c.mu.RUnlock()
+	if !present {
+		t.Fatalf("Peek must not lazily delete expired entries")
+	}
+	if s := c.Stats(); s.Hits != 0 || s.Misses != 0 {
+		t.Fatalf("Peek must not count stats, got %+v", s)
+	}
+}
+
+func TestManualCleanup(t *testing.T) {
+	startG := runtime.NumGoroutine()
+	c := NewManual()
+	if delta := runtime.NumGoroutine() - startG; delta > 0 {
+		t.Fatalf("manual cache must not start a cleanup goroutine, delta=%d", delta)
+	}
+
+	c.Set("a", 1, time.Millisecond)
+	c.Set("b", 2, time.Millisecond)
+	c.Set("c", 3, 0)
+	time.Sleep(5 * time.Millisecond)
+	if n := c.CleanupNow(); n != 2 {
+		t.Fatalf("expected CleanupNow to remove 2 entries, removed %d", n)
+	}
+	if n := c.CleanupNow(); n != 0 {
+		t.Fatalf("expected nothing left to clean, removed %d", n)
+	}
+	if _, ok := c.Get("c"); !ok {
+		t.Fatalf("non-expiring entry must survive")
+	}
+	c.Stop() // must not block without a goroutine
+}
+
+func TestGetOrDefault(t *testing.T) {
+	c := NewManual()
+	defer c.Stop()
+
+	c.Set("k", 1, 0)
+	c.Set("short", 2, time.Millisecond)
+	c.SetNegative("neg", 0)
+	if v := c.GetOrDefault("k", 0); v != 1 {
+		t.Fatalf("expected the cached value on a hit, got %v", v)
+	}
+	if v := c.GetOrDefault("missing", "def"); v != "def" {
+		t.Fatalf("expected the default on a miss, got %v", v)
+	}
+	if v := c.GetOrDefault("neg", "def"); v != "def" {
+		t.Fatalf("expected the default for a negative entry, got %v", v)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if v := c.GetOrDefault("short", "def"); v != "def" {
+		t.Fatalf("expected the default once the entry expired, got %v", v)
+	}
+	if c.Has("short") || c.Has("missing") {
+		t.Fatalf("expected expired entry reaped and the default not stored")
+	}
+	// As with Get, a negative entry counts as a miss.
+	if s := c.Stats(); s.Hits != 1 || s.Misses != 3 {
+		t.Fatalf("expected hits and misses counted as for Get, got %+v", s)
+	}
+}
+
+func TestMaxTTLClampsLifetimes(t *testing.T) {
+	c := NewWithMaxTTL(0, time.Minute)
+	defer c.Stop()
+
+	c.Set("short", 1, time.Second)
+	c.Set("long", 2, 72*time.Hour)
+	c.Set("forever", 3, 0)
+	c.SetAt("at", 4, time.Now().Add(time.Hour))
+	c.SetSliding("sliding", 5, time.Hour)
+	c.SetWithStale("stale", 6, time.Hour, time.Hour)
+	if _, ttl, _ := c.GetWithTTL("short"); ttl > time.Second {
+		t.Fatalf("expected a TTL under the cap to be kept, got %v", ttl)
+	}
+	for _, k := range []string{"long", "forever", "at", "sliding", "stale"} {
+		if _, ttl, ok := c.GetWithTTL(k); !ok || ttl <= 0 || ttl > time.Minute {
+			t.Fatalf("%s: expected TTL clamped to a minute, got %v ok=%v", k, ttl, ok)
+		}
+	}
+	c.Set("k", 7, time.Second)
+	if !c.Touch("k", 0) {
+		t.Fatalf("expected Touch to succeed")
+	}
+	if _, ttl, _ := c.GetWithTTL("k"); ttl <= 0 || ttl > time.Minute {
+		t.Fatalf("expected Touch to a never-expiring TTL clamped, got %v", ttl)
+	}
+
+	u := NewManual()
+	defer u.Stop()
+	u.Set("forever", 1, 0)
+	if _, ttl, _ := u.GetWithTTL("forever"); ttl != 0 {
+		t.Fatalf("expected no cap by default, got %v", ttl)
+	}
+}
+
+func TestNewWithSize(t *testing.T) {
+	c := NewWithSize(0, 1000)
+	defer c.Stop()
+	for i := 0; i < 2000; i++ {
+		c.Set(strconv.Itoa(i), i, 0)
+	}
+	if n := c.Len(); n != 2000 {
+		t.Fatalf("expected the size hint not to bound the cache, got %d entries", n)
+	}
+	c.Flush()
+	if c.Len() != 0 || c.sizeHint != 1000 {
+		t.Fatalf("expected Flush to empty the cache and keep the hint")
+	}
+}
+
+// benchmarkWarmUp bulk-loads warmUpEntries keys into a fresh cache per
+// iteration, as a service does at startup. Pre-sizing removes the map's growth
+// steps, which shows up as fewer bytes allocated per warm-up; the per-entry
+// allocations of Set are unaffected and dominate the rest.
+func benchmarkWarmUp(b *testing.B, newCache func() *Cache) {
+	const warmUpEntries = 200000
+	keys := make([]string, warmUpEntries)
+	for i := range keys {
+		keys[i] = "key" + strconv.Itoa(i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c := newCache()
+		for j, k := range keys {
+			c.Set(k, j, 0)
+		}
+		c.Stop()
+	}
+}
+
+func BenchmarkWarmUpDefaultMap(b *testing.B) {
+	benchmarkWarmUp(b, NewManual)
+}
+
+func BenchmarkWarmUpPresizedMap(b *testing.B) {
+	benchmarkWarmUp(b, func() *Cache { return NewWithSize(0, 200000) })
+}
+
+func TestTTLJitterSpreadsExpiry(t *testing.T) {
+	c := NewWithTTLJitter(0, 0.2)
+	defer c.Stop()
+
+	distinct := map[time.Duration]bool{}
+	for i := 0; i < 100; i++ {
+		k := strconv.Itoa(i)
+		c.Set(k, i, time.Hour)
+		_, ttl, _ := c.GetWithTTL(k)
+		if ttl < 47*time.Minute || ttl > 72*time.Minute {
+			t.Fatalf("expected the TTL within ±20%% of an hour, got %v", ttl)
+		}
+		distinct[ttl.Truncate(time.Second)] = true
+	}
+	if len(distinct) < 10 {
+		t.Fatalf("expected jitter to spread deadlines, got %d distinct TTLs", len(distinct))
+	}
+	c.Set("forever", 1, 0)
+	if _, ttl, _ := c.GetWithTTL("forever"); ttl != 0 {
+		t.Fatalf("expected a never-expiring entry to stay so, got %v", ttl)
+	}
+
+	batch := map[string]interface{}{}
+	for i := 0; i < 100; i++ {
+		batch["m"+strconv.Itoa(i)] = i
+	}
+	c.SetMulti(batch, time.Hour)
+	deadlines := map[time.Duration]bool{}
+	for k := range batch {
+		_, ttl, _ := c.GetWithTTL(k)
+		deadlines[ttl.Truncate(time.Second)] = true
+	}
+	if len(deadlines) < 10 {
+		t.Fatalf("expected SetMulti to jitter each item, got %d distinct TTLs", len(deadlines))
+	}
+
+	full := NewWithTTLJitter(0, 5) // clamped to 1
+	defer full.Stop()
+	for i := 0; i < 1000; i++ {
+		if d := jittered(time.Nanosecond, full.ttlJitter); d <= 0 {
+			t.Fatalf("expected jittered TTLs to stay positive, got %v", d)
+		}
+	}
+}
+
+func TestTTLJitterKeepsHugeTTLs(t *testing.T) {
+	c := NewWithTTLJitter(0, 0.1)
+	defer c.Stop()
+
+	huge := time.Duration(math.MaxInt64)
+	for i := 0; i < 50; i++ {
+		c.Set(strconv.Itoa(i), i, huge)
+	}
+	time.Sleep(5 * time.Millisecond)
+	c.CleanupNow()
+	if n := c.Len(); n != 50 {
+		t.Fatalf("expected jittered MaxInt64 TTLs never to expire, %d of 50 left", n)
+	}
+	for i := 0; i < 1000; i++ {
+		if d := jittered(huge, 0.5); d < huge/2 {
+			t.Fatalf("expected a huge duration not to wrap around, got %v", d)
+		}
+	}
+}
+
+func TestLoadOrStore(t *testing.T) {
+	c := NewManual()
+	defer c.Stop()
+
+	if v, loaded := c.LoadOrStore("k", 1, 0); loaded || v != 1 {
+		t.Fatalf("expected to store 1, got %v loaded=%v", v, loaded)
+	}
+	if v, loaded := c.LoadOrStore("k", 2, 0); !loaded || v != 1 {
+		t.Fatalf("expected to load 1, got %v loaded=%v", v, loaded)
+	}
+	c.Set("short", 1, time.Millisecond)
+	c.SetNegative("neg", 0)
+	time.Sleep(5 * time.Millisecond)
+	for _, k := range []string{"short", "neg"} {
+		if v, loaded := c.LoadOrStore(k, "new", time.Hour); loaded || v != "new" {
+			t.Fatalf("%s: expected an absent entry to be replaced, got %v loaded=%v", k, v, loaded)
+		}
+	}
+}
+
+func TestLoadOrStoreRace(t *testing.T) {
+	c := NewManual()
+	defer c.Stop()
+
+	for round := 0; round < 50; round++ {
+		key := strconv.Itoa(round)
+		start := make(chan struct{})
+		var stored atomic.Int32
+		actual := make([]interface{}, 2)
+		var wg sync.WaitGroup
+		for g := 0; g < 2; g++ {
+			wg.Add(1)
+			go func(g int) {
+				defer wg.Done()
+				<-start
+				v, loaded := c.LoadOrStore(key, g, 0)
+				if !loaded {
+					stored.Add(1)
+				}
+				actual[g] = v
+			}(g)
+		}
+		close(start)
+		wg.Wait()
+		if stored.Load() != 1 || actual[0] != actual[1] {
+			t.Fatalf("round %d: expected one store and both callers to agree, got %d stores and %v", round, stored.Load(), actual)
+		}
+	}
+}
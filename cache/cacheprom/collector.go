@@ -0,0 +1,64 @@
+// Package cacheprom exports cache statistics as Prometheus metrics. It is a
+// separate module, so that the cache package itself stays free of dependencies.
+package cacheprom
+
+import (
+	"github.com/UtkrushtApps/go-concurrent-cache-answers/cache"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector is a prometheus.Collector reading a Cache's Stats on every scrape.
+type Collector struct {
+	c *cache.Cache
+
+	hits, misses, evictions, expirations *prometheus.Desc
+	entries, cleanupDuration             *prometheus.Desc
+}
+
+var _ prometheus.Collector = (*Collector)(nil)
+
+// NewCollector returns a Collector for c whose metric names are prefixed with
+// namespace and subsystem, e.g. "myapp_sessions_cache_hits_total". Register it
+// with the caller's registry:
+//
+//	reg.MustRegister(cacheprom.NewCollector(c, "myapp", "sessions"))
+//
+// Registering two collectors for different caches on one registry requires
+// different namespace/subsystem pairs.
+func NewCollector(c *cache.Cache, namespace, subsystem string) *Collector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, subsystem, name), help, nil, nil)
+	}
+	return &Collector{
+		c:               c,
+		hits:            desc("cache_hits_total", "Lookups that found a live entry."),
+		misses:          desc("cache_misses_total", "Lookups for absent or expired keys."),
+		evictions:       desc("cache_evictions_total", "Entries evicted to stay within capacity."),
+		expirations:     desc("cache_expirations_total", "Entries removed because their TTL passed."),
+		entries:         desc("cache_entries", "Number of live entries."),
+		cleanupDuration: desc("cache_cleanup_duration_seconds", "Duration of the latest cleanup sweep."),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (col *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- col.hits
+	ch <- col.misses
+	ch <- col.evictions
+	ch <- col.expirations
+	ch <- col.entries
+	ch <- col.cleanupDuration
+}
+
+// Collect implements prometheus.Collector. Counters come from Stats, which is
+// lock-free; the entry count comes from Len, which scans the cache under its
+// read lock.
+func (col *Collector) Collect(ch chan<- prometheus.Metric) {
+	s := col.c.Stats()
+	ch <- prometheus.MustNewConstMetric(col.hits, prometheus.CounterValue, float64(s.Hits))
+	ch <- prometheus.MustNewConstMetric(col.misses, prometheus.CounterValue, float64(s.Misses))
+	ch <- prometheus.MustNewConstMetric(col.evictions, prometheus.CounterValue, float64(s.Evictions))
+	ch <- prometheus.MustNewConstMetric(col.expirations, prometheus.CounterValue, float64(s.Expirations))
+	ch <- prometheus.MustNewConstMetric(col.entries, prometheus.GaugeValue, float64(col.c.Len()))
+	ch <- prometheus.MustNewConstMetric(col.cleanupDuration, prometheus.GaugeValue, s.LastCleanupDuration.Seconds())
+}
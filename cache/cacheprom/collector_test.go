@@ -0,0 +1,49 @@
+package cacheprom
+
+import (
+	"testing"
+	"time"
+
+	"github.com/UtkrushtApps/go-concurrent-cache-answers/cache"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestCollectorExportsStats(t *testing.T) {
+	c := cache.New(time.Hour)
+	defer c.Stop()
+	c.Set("a", 1, 0)
+	c.Get("a")
+	c.Get("missing")
+
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(NewCollector(c, "test", "cache"))
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather: %v", err)
+	}
+
+	got := make(map[string]float64)
+	for _, mf := range families {
+		m := mf.GetMetric()[0]
+		if m.GetCounter() != nil {
+			got[mf.GetName()] = m.GetCounter().GetValue()
+		} else {
+			got[mf.GetName()] = m.GetGauge().GetValue()
+		}
+	}
+	want := map[string]float64{
+		"test_cache_cache_hits_total":        1,
+		"test_cache_cache_misses_total":      1,
+		"test_cache_cache_evictions_total":   0,
+		"test_cache_cache_expirations_total": 0,
+		"test_cache_cache_entries":           1,
+	}
+	for name, v := range want {
+		if got[name] != v {
+			t.Errorf("%s: want %v, got %v", name, v, got[name])
+		}
+	}
+	if _, ok := got["test_cache_cache_cleanup_duration_seconds"]; !ok {
+		t.Errorf("missing cleanup duration metric")
+	}
+}
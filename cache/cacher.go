@@ -0,0 +1,24 @@
+package cache
+
+import "time"
+
+// Cacher is the core cache API, for code that wants to depend on an interface
+// and substitute a fake in its tests. *Cache is the canonical implementation;
+// *ShardedCache implements it too. The interface is deliberately small, so that
+// fakes stay easy to write: code needing more of the API can declare its own
+// interface with just the methods it uses.
+type Cacher interface {
+	// Set inserts or updates a value with optional TTL; ttl <= 0 never expires.
+	Set(key string, value interface{}, ttl time.Duration)
+	// Get returns the value for key and whether it was found and not expired.
+	Get(key string) (interface{}, bool)
+	// Delete removes key.
+	Delete(key string)
+	// Stop releases the cache's background resources.
+	Stop()
+}
+
+var (
+	_ Cacher = (*Cache)(nil)
+	_ Cacher = (*ShardedCache)(nil)
+)
@@ -0,0 +1,36 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+// mapCacher is the kind of fake a consumer would inject in place of a Cache.
+type mapCacher map[string]interface{}
+
+func (m mapCacher) Set(key string, value interface{}, ttl time.Duration) { m[key] = value }
+func (m mapCacher) Get(key string) (interface{}, bool)                   { v, ok := m[key]; return v, ok }
+func (m mapCacher) Delete(key string)                                    { delete(m, key) }
+func (m mapCacher) Stop()                                                {}
+
+func TestCacherImplementations(t *testing.T) {
+	// remember stands in for consumer code written against the interface.
+	remember := func(c Cacher) (interface{}, bool) {
+		c.Set("k", 1, time.Minute)
+		return c.Get("k")
+	}
+	for name, c := range map[string]Cacher{
+		"Cache":        NewManual(),
+		"ShardedCache": NewSharded(0, 4),
+		"fake":         mapCacher{},
+	} {
+		if v, ok := remember(c); !ok || v != 1 {
+			t.Errorf("%s: expected 1 through the interface, got %v ok=%v", name, v, ok)
+		}
+		c.Delete("k")
+		if _, ok := c.Get("k"); ok {
+			t.Errorf("%s: expected k deleted", name)
+		}
+		c.Stop()
+	}
+}
@@ -0,0 +1,22 @@
+package cachetest
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	f := NewFakeClock(start)
+	if !f.Now().Equal(start) {
+		t.Fatalf("expected %v, got %v", start, f.Now())
+	}
+	f.Advance(time.Minute)
+	if got := f.Now(); !got.Equal(start.Add(time.Minute)) {
+		t.Fatalf("expected Advance to move the clock, got %v", got)
+	}
+	f.Set(start)
+	if !f.Now().Equal(start) {
+		t.Fatalf("expected Set to move the clock back, got %v", f.Now())
+	}
+}
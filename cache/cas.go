@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"reflect"
+	"time"
+)
+
+// CompareAndSwap replaces the value of key with new, and its TTL with ttl, only
+// if the current value equals old according to reflect.DeepEqual. It reports
+// whether the swap happened. A missing or expired key fails the swap, except
+// that an old of nil means "insert if absent": new is then stored if key has no
+// live entry. The comparison and the write happen under one write lock.
+func (c *Cache) CompareAndSwap(key string, old, new interface{}, ttl time.Duration) bool {
+	key = c.normalize(key)
+	now := c.now()
+	c.mu.Lock()
+	defer c.unlock()
+	e, ok := c.data[key]
+	if ok && e.expired(now) {
+		c.removeLocked(key, e, ReasonExpired)
+		ok = false
+	}
+	if !ok {
+		if old != nil {
+			return false
+		}
+	} else if !reflect.DeepEqual(e.value, old) {
+		return false
+	}
+	return c.setLocked(key, entry{value: new, expiresAt: c.expiry(now, ttl)})
+}
+
+// Swap stores value with ttl (never expiring if ttl <= 0) and returns the value
+// it replaced, under one write lock, so each old value is handed to exactly one
+// caller for cleanup. had is false, and old nil, if key had no live entry; an
+// expired previous entry is removed as expired, and a negative one is simply
+// overwritten. Replacing a value is not a removal, so OnEvict does not fire for
+// old.
+func (c *Cache) Swap(key string, value interface{}, ttl time.Duration) (old interface{}, had bool) {
+	key = c.normalize(key)
+	now := c.now()
+	c.mu.Lock()
+	defer c.unlock()
+	if e, ok := c.data[key]; ok {
+		if e.expired(now) {
+			c.removeLocked(key, e, ReasonExpired)
+		} else if !e.negative {
+			old, had = c.copyOut(e.value), true
+		}
+	}
+	if !c.setLocked(key, entry{value: value, expiresAt: c.expiry(now, ttl)}) {
+		return nil, false
+	}
+	return old, had
+}
+
+// RenewIfOwner resets the TTL of key to ttl (never expiring if ttl <= 0), but only
+// if its current value equals value according to reflect.DeepEqual, and reports
+// whether it did. Unlike Touch it refuses to renew an entry someone else has
+// overwritten, and unlike CompareAndSwap it never changes the value. A missing or
+// expired entry is not owned by anyone and fails.
+func (c *Cache) RenewIfOwner(key string, value interface{}, ttl time.Duration) bool {
+	key = c.normalize(key)
+	now := c.now()
+	c.mu.Lock()
+	defer c.unlock()
+	e, ok := c.data[key]
+	if !ok {
+		return false
+	}
+	if e.expired(now) {
+		c.removeLocked(key, e, ReasonExpired)
+		return false
+	}
+	if !reflect.DeepEqual(e.value, value) {
+		return false
+	}
+	c.setExpiryLocked(key, e, c.expiry(now, ttl))
+	return true
+}
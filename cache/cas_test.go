@@ -0,0 +1,119 @@
+package cache
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCompareAndSwap(t *testing.T) {
+	c := New(time.Hour)
+	defer c.Stop()
+
+	if c.CompareAndSwap("state", "idle", "running", 0) {
+		t.Fatalf("swap on a missing key with non-nil old must fail")
+	}
+	if !c.CompareAndSwap("state", nil, "idle", 0) {
+		t.Fatalf("nil old must insert if absent")
+	}
+	if c.CompareAndSwap("state", nil, "other", 0) {
+		t.Fatalf("nil old must not overwrite a live entry")
+	}
+	if !c.CompareAndSwap("state", "idle", "running", 0) {
+		t.Fatalf("expected swap from idle to running")
+	}
+	if v, _ := c.Get("state"); v != "running" {
+		t.Fatalf("expected running, got %v", v)
+	}
+
+	c.Set("list", []int{1, 2}, 0)
+	if !c.CompareAndSwap("list", []int{1, 2}, []int{1, 2, 3}, 0) {
+		t.Fatalf("expected deep-equal comparison of slices")
+	}
+
+	c.Set("gone", "v", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	if c.CompareAndSwap("gone", "v", "w", 0) {
+		t.Fatalf("swap on an expired key must fail")
+	}
+}
+
+func TestCompareAndSwapSingleWinner(t *testing.T) {
+	c := New(time.Hour)
+	defer c.Stop()
+	c.Set("k", 0, 0)
+
+	var wins int64
+	var wg sync.WaitGroup
+	for i := 1; i <= 20; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			if c.CompareAndSwap("k", 0, id, 0) {
+				atomic.AddInt64(&wins, 1)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if wins != 1 {
+		t.Fatalf("expected exactly one successful swap, got %d", wins)
+	}
+}
+
+func TestRenewIfOwner(t *testing.T) {
+	c := New(time.Hour)
+	defer c.Stop()
+
+	c.Set("lease", "worker-1", 20*time.Millisecond)
+	if !c.RenewIfOwner("lease", "worker-1", time.Minute) {
+		t.Fatalf("owner must be able to renew its lease")
+	}
+	time.Sleep(30 * time.Millisecond)
+	if v, _ := c.Get("lease"); v != "worker-1" {
+		t.Fatalf("renewed lease should still be held, got %v", v)
+	}
+	if c.RenewIfOwner("lease", "worker-2", time.Minute) {
+		t.Fatalf("non-owner must not renew")
+	}
+
+	c.Set("lease", "worker-2", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	if c.RenewIfOwner("lease", "worker-2", time.Minute) {
+		t.Fatalf("expired lease must not be renewable")
+	}
+}
+
+func TestSwap(t *testing.T) {
+	c := NewManual()
+	defer c.Stop()
+
+	if old, had := c.Swap("k", 1, 0); had || old != nil {
+		t.Fatalf("expected no previous value, got %v had=%v", old, had)
+	}
+	if old, had := c.Swap("k", 2, time.Hour); !had || old != 1 {
+		t.Fatalf("expected to get 1 back, got %v had=%v", old, had)
+	}
+	if v, ttl, _ := c.GetWithTTL("k"); v != 2 || ttl <= 0 {
+		t.Fatalf("expected the new value and TTL stored, got %v ttl=%v", v, ttl)
+	}
+
+	var expired []string
+	c.OnEvict(func(key string, value interface{}, reason EvictReason) {
+		if reason == ReasonExpired {
+			expired = append(expired, key)
+		}
+	})
+	c.Set("short", 1, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	if old, had := c.Swap("short", 2, 0); had || old != nil {
+		t.Fatalf("expected an expired entry to report had=false, got %v had=%v", old, had)
+	}
+	if len(expired) != 1 {
+		t.Fatalf("expected the expired entry reported as expired, got %v", expired)
+	}
+	c.SetNegative("neg", 0)
+	if _, had := c.Swap("neg", 1, 0); had {
+		t.Fatalf("expected a negative entry to report had=false")
+	}
+}
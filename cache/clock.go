@@ -0,0 +1,31 @@
+package cache
+
+import "time"
+
+// Clock is the cache's source of the current time. Every TTL deadline, expiry
+// check, creation and access time is taken from it, so a test can substitute a
+// fake clock (see the cachetest package) and advance it instead of sleeping.
+// Batch operations such as GetMulti, SetItems or DeleteExpired read it once,
+// so every entry they touch is judged against, or stamped with, the same
+// instant. Implementations must be safe for concurrent use.
+type Clock interface {
+	Now() time.Time
+}
+
+// NewWithClock creates a Cache that reads the time from clock instead of the
+// system clock. Only the notion of "now" moves to clock: the cleanup goroutine
+// still wakes every cleanerInterval of real time, as do SetRefreshing timers.
+// With a fake clock, pass a cleanerInterval <= 0 and call CleanupNow after
+// advancing the clock to reap expired entries deterministically. A nil clock
+// means the system clock.
+func NewWithClock(cleanerInterval time.Duration, clock Clock) *Cache {
+	return NewWithOptions(cleanerInterval, WithClock(clock))
+}
+
+// now returns the current time according to the cache's clock.
+func (c *Cache) now() time.Time {
+	if c.clock == nil {
+		return time.Now()
+	}
+	return c.clock.Now()
+}
@@ -0,0 +1,102 @@
+package cache
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/UtkrushtApps/go-concurrent-cache-answers/cache/cachetest"
+)
+
+func TestFakeClockDrivesExpiry(t *testing.T) {
+	clock := cachetest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	c := NewWithClock(0, clock)
+	defer c.Stop()
+
+	c.Set("k", 1, time.Hour)
+	c.Set("long", 2, 2*time.Hour)
+	clock.Advance(59 * time.Minute)
+	if _, ttl, ok := c.GetWithTTL("k"); !ok || ttl != time.Minute {
+		t.Fatalf("expected a minute left on the fake clock, got %v ok=%v", ttl, ok)
+	}
+	clock.Advance(time.Minute + time.Nanosecond)
+	if _, ok := c.Get("k"); ok {
+		t.Fatalf("expected k to expire once the clock passes its deadline")
+	}
+	if n := c.CleanupNow(); n != 0 {
+		t.Fatalf("expected nothing else due, removed %d", n)
+	}
+	clock.Advance(time.Hour)
+	if n := c.CleanupNow(); n != 1 {
+		t.Fatalf("expected the sweep to follow the fake clock, removed %d", n)
+	}
+	c.Set("new", 3, 0)
+	if m, _ := c.GetMeta("new"); !m.CreatedAt.Equal(clock.Now()) {
+		t.Fatalf("expected CreatedAt from the fake clock, got %v", m.CreatedAt)
+	}
+}
+
+// steppingClock moves forward by a millisecond on every read and counts the
+// reads, so an operation that consults the clock more than once sees drift.
+type steppingClock struct {
+	mu    sync.Mutex
+	now   time.Time
+	reads int
+}
+
+func (s *steppingClock) Now() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reads++
+	s.now = s.now.Add(time.Millisecond)
+	return s.now
+}
+
+func TestMultiEntryOperationsReadTheClockOnce(t *testing.T) {
+	keys := []string{"k0", "k1", "k2", "k3", "k4", "k5", "k6", "k7"}
+	ops := map[string]func(c *Cache) int{
+		"GetMulti": func(c *Cache) int { return len(c.GetMulti(keys)) },
+		"GetBatch": func(c *Cache) int { _, misses := c.GetBatch(keys); return len(keys) - len(misses) },
+		"Keys":     func(c *Cache) int { return len(c.Keys()) },
+		"Snapshot": func(c *Cache) int { return len(c.Snapshot()) },
+		"Range": func(c *Cache) int {
+			n := 0
+			c.Range(func(string, interface{}) bool { n++; return true })
+			return n
+		},
+		"DeleteExpired": func(c *Cache) int { return len(keys) - c.DeleteExpired() },
+		"DeleteMulti":   func(c *Cache) int { return c.DeleteMulti(keys) },
+		"DeleteIf": func(c *Cache) int {
+			return c.DeleteIf(func(string, interface{}) bool { return true })
+		},
+	}
+	for name, op := range ops {
+		for _, capacity := range []int{0, 100} {
+			clock := &steppingClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+			c := NewWithOptions(0, WithClock(clock), WithCapacity(capacity))
+			// Deadlines one millisecond apart, straddling the instant the
+			// operation reads: k0-k2 have passed by then, k3-k7 have not.
+			items := make([]Item, len(keys))
+			for i, k := range keys {
+				items[i] = Item{Key: k, Value: i, TTL: time.Duration(i+1) * time.Millisecond}
+			}
+			c.SetItems(items)
+			if clock.reads != 1 {
+				t.Fatalf("SetItems read the clock %d times", clock.reads)
+			}
+			clock.mu.Lock()
+			clock.now = clock.now.Add(3 * time.Millisecond)
+			clock.mu.Unlock()
+
+			clock.reads = 0
+			live := op(c)
+			if clock.reads != 1 {
+				t.Errorf("%s (capacity=%d): read the clock %d times", name, capacity, clock.reads)
+			}
+			if live != 5 {
+				t.Errorf("%s (capacity=%d): expected the 5 entries live at a single instant, got %d", name, capacity, live)
+			}
+			c.Stop()
+		}
+	}
+}
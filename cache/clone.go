@@ -0,0 +1,84 @@
+package cache
+
+import (
+	"container/list"
+	"sync/atomic"
+)
+
+// Clone returns an independent copy of the cache: a new Cache, with its own
+// cleanup goroutine, the same cleanerInterval and configuration (capacity and
+// size bounds, copy mode, key function, clock, insertion-order mode, default
+// and maximum TTL, TTL jitter, read-through loader, refresh worker limit,
+// circuit breaker settings and cleanup tuning including adaptive cleanup
+// bounds, though a custom eviction policy is replaced by the built-in LRU),
+// holding every live entry with its deadline, sliding or stale windows, tags
+// and weight. Recency order is preserved, as is insertion order unless the
+// cache is also bounded, in which case the clone's insertion order follows
+// recency. Expired entries, stats, circuit breaker state, dependencies,
+// OnEvict callbacks, the ForEachExpired hook, the drain set by
+// WithDrainOnStop, the logger, OnStale revalidation, subscriptions and
+// SetRefreshing registrations are not carried over.
+//
+// Writes to either cache never affect the other. Values themselves are shared,
+// as with Get, unless the cache is in copy mode, in which case the clone holds
+// copies of them.
+func (c *Cache) Clone() *Cache {
+	now := c.now()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	n := newCache(c.cleanerInterval)
+	n.maxEntries = c.maxEntries
+	n.maxBytes = c.maxBytes
+	n.maxValueSize = c.maxValueSize
+	n.sizer = c.sizer
+	n.jitter = c.jitter
+	n.copyValues = c.copyValues
+	n.cleanupBatch = c.cleanupBatch
+	n.sampleEvery, n.sampleSize = c.sampleEvery, c.sampleSize
+	n.minInterval, n.maxInterval = c.minInterval, c.maxInterval
+	n.defaultTTL = c.defaultTTL
+	n.maxTTL = c.maxTTL
+	n.ttlJitter = c.ttlJitter
+	n.keyFunc = c.keyFunc
+	n.clock = c.clock
+	n.readThrough = c.readThrough
+	n.refreshPool.limit = c.refreshPool.limit
+	if c.breaker != nil {
+		n.breaker = &breaker{threshold: c.breaker.threshold, cooldown: c.breaker.cooldown}
+	}
+	if c.lru != nil || c.policy != nil {
+		n.ensureLRU()
+	}
+	if c.order != nil {
+		n.order = list.New()
+	}
+
+	clone := func(k string, e entry) {
+		if e.expired(now) {
+			return
+		}
+		accessed := new(atomic.Int64)
+		accessed.Store(e.accessed.Load())
+		e.accessed = accessed
+		e.elem, e.welem, e.oelem = nil, nil, nil
+		e.refresh, e.refreshing = nil, false
+		e.tags = append([]string(nil), e.tags...)
+		e.parents = nil
+		n.setLocked(k, e)
+	}
+	if c.lru != nil {
+		// Oldest first, so that each insert lands in front of the previous one.
+		for el := c.lru.Back(); el != nil; el = el.Prev() {
+			k := el.Value.(string)
+			clone(k, c.data[k])
+		}
+	} else {
+		c.eachLocked(func(k string, e entry) bool {
+			clone(k, e)
+			return true
+		})
+	}
+	n.start()
+	return n
+}
@@ -0,0 +1,61 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCloneIsIndependent(t *testing.T) {
+	c := NewWithCapacity(time.Hour, 3)
+	defer c.Stop()
+
+	c.Set("a", 1, 0)
+	c.SetWithTags("b", 2, time.Minute, "t")
+	c.Set("c", 3, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	c.Get("a") // a becomes the most recent
+
+	n := c.Clone()
+	defer n.Stop()
+
+	if n.Len() != 2 || n.Has("c") {
+		t.Fatalf("expected the live entries only, got %v", n.Keys())
+	}
+	if got := n.MRUKeys(2); len(got) != 2 || got[0] != "a" {
+		t.Fatalf("expected recency order to carry over, got %v", got)
+	}
+	if _, ttl, _ := n.GetWithTTL("b"); ttl <= 0 || ttl > time.Minute {
+		t.Fatalf("expected b to keep its deadline, got %v", ttl)
+	}
+
+	if n.InvalidateTag("t") != 1 || !c.Has("b") {
+		t.Fatalf("expected tag indexes to be independent")
+	}
+	n.Set("a", 100, 0)
+	if v, _ := c.Get("a"); v != 1 {
+		t.Fatalf("expected writes to the clone not to reach the original")
+	}
+	c.Set("z", 26, 0)
+	if n.Has("z") {
+		t.Fatalf("expected writes to the original not to reach the clone")
+	}
+	if n.ctx == c.ctx {
+		t.Fatalf("expected the clone to have its own context")
+	}
+}
+
+func TestCloneKeepsAdaptiveCleanupButNotHooks(t *testing.T) {
+	c := NewWithOptions(0, WithAdaptiveCleanup(time.Millisecond, time.Minute),
+		WithDrainOnStop(func([]Item) {}))
+	defer c.Stop()
+	c.ForEachExpired(func(string, interface{}) {})
+
+	n := c.Clone()
+	defer n.Stop()
+	if n.minInterval != time.Millisecond || n.maxInterval != time.Minute {
+		t.Fatalf("expected the adaptive bounds to carry over, got [%v, %v]", n.minInterval, n.maxInterval)
+	}
+	if n.drain != nil || n.onSwept != nil {
+		t.Fatalf("expected the drain and the ForEachExpired hook to be left behind")
+	}
+}
@@ -0,0 +1,56 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// NewWithCopy creates a Cache that stores and returns deep copies of values, so
+// that a caller mutating a slice, map or struct it passed to Set (or got from
+// Get) cannot corrupt the cached copy.
+//
+// Copies are made with an encoding/gob round trip, which is far slower than the
+// default pointer handoff and allocates on every read and write; store-side copies
+// are taken under the write lock. Only gob-encodable values are supported: Set
+// panics on a value gob cannot encode, and gob's rules apply to the copy
+// (unexported fields are dropped, empty slices and maps may come back nil,
+// pointers are followed). Values reach OnEvict callbacks uncopied.
+func NewWithCopy(cleanerInterval time.Duration) *Cache {
+	return NewWithOptions(cleanerInterval, WithCopy())
+}
+
+// copyIn returns the value to store for v.
+func (c *Cache) copyIn(v interface{}) interface{} {
+	if !c.copyValues {
+		return v
+	}
+	return mustClone(v)
+}
+
+// copyOut returns the value to hand to a caller for the stored value v.
+func (c *Cache) copyOut(v interface{}) interface{} {
+	if !c.copyValues {
+		return v
+	}
+	return mustClone(v)
+}
+
+// mustClone deep-copies v with a gob round trip through its concrete type,
+// which avoids the need to gob.Register it.
+func mustClone(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		panic(fmt.Sprintf("cache: cannot copy value of type %T: %v", v, err))
+	}
+	p := reflect.New(reflect.TypeOf(v))
+	if err := gob.NewDecoder(&buf).DecodeValue(p); err != nil {
+		panic(fmt.Sprintf("cache: cannot copy value of type %T: %v", v, err))
+	}
+	return p.Elem().Interface()
+}
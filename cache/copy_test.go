@@ -0,0 +1,71 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+type copyRecord struct {
+	Name string
+	Tags []string
+}
+
+func TestCopyModeIsolatesValues(t *testing.T) {
+	c := NewWithCopy(time.Hour)
+	defer c.Stop()
+
+	in := []int{1, 2, 3}
+	c.Set("s", in, 0)
+	in[0] = 99 // mutating the caller's slice must not reach the cache
+
+	out, _ := c.Get("s")
+	got := out.([]int)
+	if got[0] != 1 {
+		t.Fatalf("cached slice was mutated through the caller's reference: %v", got)
+	}
+	got[1] = 42 // nor may mutating a returned value
+	again, _ := c.Get("s")
+	if again.([]int)[1] != 2 {
+		t.Fatalf("cached slice was mutated through a returned reference: %v", again)
+	}
+
+	c.Set("rec", copyRecord{Name: "a", Tags: []string{"x"}}, 0)
+	r, _ := c.Get("rec")
+	r.(copyRecord).Tags[0] = "y"
+	if r2, _ := c.Get("rec"); r2.(copyRecord).Tags[0] != "x" {
+		t.Fatalf("struct fields must be deep-copied, got %v", r2)
+	}
+
+	m := c.GetMulti([]string{"s"})
+	m["s"].([]int)[2] = 7
+	if v, _ := c.Peek("s"); v.([]int)[2] != 3 {
+		t.Fatalf("GetMulti must return copies, got %v", v)
+	}
+}
+
+func TestCopyModeRejectsUnencodable(t *testing.T) {
+	c := NewWithCopy(time.Hour)
+	defer c.Stop()
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected Set to panic for a value gob cannot encode")
+		}
+	}()
+	c.Set("fn", func() {}, 0)
+}
+
+func TestCopyModeCopiesAsyncSetValues(t *testing.T) {
+	c := NewWithCopy(time.Hour)
+	defer c.Stop()
+
+	in := []int{1, 2, 3}
+	c.mu.Lock() // hold the drainer off until the caller has reused its slice
+	c.AsyncSet("s", in, 0)
+	in[0] = 99
+	c.mu.Unlock()
+	c.WaitAsync()
+
+	if v, _ := c.Get("s"); v.([]int)[0] != 1 {
+		t.Fatalf("expected AsyncSet to copy the value when called, got %v", v)
+	}
+}
@@ -0,0 +1,54 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNotInt64 is returned by Increment and Decrement when the stored value is
+// not an int64.
+var ErrNotInt64 = errors.New("cache: value is not an int64")
+
+// Increment atomically adds delta to the int64 stored under key and returns the
+// new total. An existing entry keeps its current expiry, while its CreatedAt and
+// LastAccessedAt start over as for any write; if key is absent, expired or holds
+// a negative entry (see SetNegative) it is created with value delta and the
+// given TTL (never expiring if ttl <= 0). If the stored value is not an int64
+// the entry is left untouched and an error wrapping ErrNotInt64 is returned. A
+// write refused for exceeding the maximum value size returns ErrValueTooLarge
+// and stores nothing.
+func (c *Cache) Increment(key string, delta int64, ttl time.Duration) (int64, error) {
+	key = c.normalize(key)
+	now := c.now()
+	c.mu.Lock()
+	defer c.unlock()
+	if c.frozen.Load() {
+		return 0, ErrFrozen
+	}
+	e, ok := c.data[key]
+	if ok && e.expired(now) {
+		c.removeLocked(key, e, ReasonExpired)
+		ok = false
+	}
+	if !ok || e.negative {
+		if !c.setLocked(key, entry{value: delta, expiresAt: c.expiry(now, ttl)}) {
+			return 0, ErrValueTooLarge
+		}
+		return delta, nil
+	}
+	n, isInt := e.value.(int64)
+	if !isInt {
+		return 0, fmt.Errorf("%w: %q holds %T", ErrNotInt64, key, e.value)
+	}
+	e.value, e.createdAt, e.accessed = n+delta, now, nil
+	if !c.setLocked(key, e) {
+		return 0, ErrValueTooLarge
+	}
+	return n + delta, nil
+}
+
+// Decrement is Increment with the sign of delta reversed.
+func (c *Cache) Decrement(key string, delta int64, ttl time.Duration) (int64, error) {
+	return c.Increment(key, -delta, ttl)
+}
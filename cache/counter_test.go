@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestIncrementConcurrent(t *testing.T) {
+	c := New(time.Hour)
+	defer c.Stop()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if _, err := c.Increment("hits", 1, time.Minute); err != nil {
+					t.Errorf("Increment: %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if v, _ := c.Get("hits"); v != int64(1000) {
+		t.Fatalf("expected 1000, got %v", v)
+	}
+	if n, err := c.Decrement("hits", 400, 0); err != nil || n != 600 {
+		t.Fatalf("expected 600, got %d err=%v", n, err)
+	}
+	if _, ttl, _ := c.GetWithTTL("hits"); ttl <= 0 {
+		t.Fatalf("expected existing TTL to be preserved, got %v", ttl)
+	}
+}
+
+func TestIncrementInitializesAndRejectsNonInt(t *testing.T) {
+	c := New(time.Hour)
+	defer c.Stop()
+
+	c.Set("old", int64(50), time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	if n, err := c.Increment("old", 3, 0); err != nil || n != 3 {
+		t.Fatalf("expected expired counter to restart at 3, got %d err=%v", n, err)
+	}
+
+	c.Set("name", "x", 0)
+	if _, err := c.Increment("name", 1, 0); !errors.Is(err, ErrNotInt64) {
+		t.Fatalf("expected ErrNotInt64, got %v", err)
+	}
+	if v, _ := c.Get("name"); v != "x" {
+		t.Fatalf("failed increment must not modify the value, got %v", v)
+	}
+}
+
+func TestIncrementNegativeAndRefusedWrites(t *testing.T) {
+	c := NewWithMaxValueSize(time.Hour, 8, func(v interface{}) int64 {
+		if n, ok := v.(int64); ok && n > 100 {
+			return 16
+		}
+		return 8
+	})
+	defer c.Stop()
+
+	c.SetNegative("k", 0)
+	if n, err := c.Increment("k", 2, 0); err != nil || n != 2 {
+		t.Fatalf("expected a negative entry to count as absent, got %d err=%v", n, err)
+	}
+	if _, err := c.Increment("k", 200, 0); !errors.Is(err, ErrValueTooLarge) {
+		t.Fatalf("expected a refused write to return ErrValueTooLarge, got %v", err)
+	}
+	if v, _ := c.Get("k"); v != int64(2) {
+		t.Fatalf("expected the refused total not to be stored, got %v", v)
+	}
+	if _, err := c.Increment("fresh", 200, 0); !errors.Is(err, ErrValueTooLarge) || c.Has("fresh") {
+		t.Fatalf("expected a refused new counter to return ErrValueTooLarge, got %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package cache
+
+import (
+	"strings"
+	"time"
+)
+
+// DeletePrefix deletes every entry whose key starts with prefix, under a single
+// write lock, and returns how many live entries it removed. OnEvict fires with
+// ReasonDeleted for each; LRU, size and tag bookkeeping is updated as for Delete.
+// It scans every key, so it is O(n) in the size of the cache.
+func (c *Cache) DeletePrefix(prefix string) int {
+	prefix = c.normalize(prefix)
+	now := c.now()
+	c.mu.Lock()
+	defer c.unlock()
+	n := 0
+	for k, e := range c.data {
+		if !strings.HasPrefix(k, prefix) {
+			continue
+		}
+		if c.deleteLocked(k, e, now) {
+			n++
+		}
+	}
+	return n
+}
+
+// DeleteMulti deletes every key in keys under a single write-lock acquisition
+// and returns how many of them had a live entry. Bookkeeping and OnEvict
+// callbacks are as for Delete; an expired key is reaped as expired and not
+// counted. Keys repeated in the list are counted once.
+func (c *Cache) DeleteMulti(keys []string) int {
+	now := c.now()
+	c.mu.Lock()
+	defer c.unlock()
+	n := 0
+	for _, k := range keys {
+		k = c.normalize(k)
+		if e, ok := c.data[k]; ok && c.deleteLocked(k, e, now) {
+			n++
+		}
+	}
+	return n
+}
+
+// DeleteIf deletes every live entry for which pred returns true, under a single
+// write lock, and returns how many it removed. Expired and negative entries are
+// not offered to pred. OnEvict fires with ReasonDeleted for each removal, and
+// bookkeeping is updated as for Delete. It scans every entry, so it is O(n) in
+// the size of the cache.
+//
+// pred runs with the write lock held: it must not call any method on the same
+// cache (doing so deadlocks), and it must not retain value past its return.
+func (c *Cache) DeleteIf(pred func(key string, value interface{}) bool) int {
+	now := c.now()
+	c.mu.Lock()
+	defer c.unlock()
+	if c.frozen.Load() {
+		return 0
+	}
+	n := 0
+	for k, e := range c.data {
+		if e.negative || e.expired(now) || !pred(k, c.copyOut(e.value)) {
+			continue
+		}
+		c.removeLocked(k, e, ReasonDeleted)
+		n++
+	}
+	return n
+}
+
+// deleteLocked removes e as an explicit delete and reports whether it was live.
+// An already-expired entry is removed as expired instead. c.mu must be held for
+// writing.
+func (c *Cache) deleteLocked(key string, e entry, now time.Time) bool {
+	if c.frozen.Load() {
+		return false
+	}
+	if e.expired(now) {
+		c.removeLocked(key, e, ReasonExpired)
+		return false
+	}
+	c.removeLocked(key, e, ReasonDeleted)
+	return true
+}
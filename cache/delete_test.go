@@ -0,0 +1,83 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDeletePrefix(t *testing.T) {
+	c := NewWithCapacity(time.Hour, 10)
+	defer c.Stop()
+
+	c.Set("user:123:profile", 1, 0)
+	c.SetWithTags("user:123:prefs", 2, 0, "prefs")
+	c.Set("user:1234:profile", 3, 0)
+	c.Set("user:123:gone", 4, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	if n := c.DeletePrefix("user:123:"); n != 2 {
+		t.Fatalf("expected 2 live entries removed, got %d", n)
+	}
+	if keys := c.Keys(); len(keys) != 1 || keys[0] != "user:1234:profile" {
+		t.Fatalf("expected only user:1234:profile to remain, got %v", keys)
+	}
+	if c.lru.Len() != 1 || len(c.tags) != 0 {
+		t.Fatalf("bookkeeping out of sync: lru=%d tags=%v", c.lru.Len(), c.tags)
+	}
+}
+
+func TestDeleteIf(t *testing.T) {
+	c := NewWithCapacity(time.Hour, 10)
+	defer c.Stop()
+
+	type record struct{ version int }
+	c.Set("a", record{1}, 0)
+	c.SetWithTags("b", record{2}, 0, "t")
+	c.Set("c", record{1}, 0)
+	c.Set("gone", record{1}, time.Millisecond)
+	c.SetNegative("neg", 0)
+	time.Sleep(5 * time.Millisecond)
+
+	var reasons []EvictReason
+	c.OnEvict(func(key string, value interface{}, reason EvictReason) {
+		reasons = append(reasons, reason)
+	})
+	seen := 0
+	n := c.DeleteIf(func(key string, value interface{}) bool {
+		seen++
+		return value.(record).version < 2
+	})
+	if n != 2 || seen != 3 {
+		t.Fatalf("expected 2 of 3 live entries removed, got n=%d seen=%d", n, seen)
+	}
+	if _, ok := c.Get("b"); !ok || c.Has("a") || c.Has("c") {
+		t.Fatalf("expected only b to survive")
+	}
+	if len(reasons) != 2 || reasons[0] != ReasonDeleted {
+		t.Fatalf("expected ReasonDeleted callbacks, got %v", reasons)
+	}
+	if c.lru.Len() != 3 {
+		t.Fatalf("expected lru to hold b, gone and neg, got %d", c.lru.Len())
+	}
+}
+
+func TestDeleteMulti(t *testing.T) {
+	c := NewWithCapacity(time.Hour, 10)
+	defer c.Stop()
+
+	c.Set("a", 1, 0)
+	c.SetWithTags("b", 2, 0, "t")
+	c.Set("c", 3, 0)
+	c.Set("gone", 4, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	if n := c.DeleteMulti([]string{"a", "b", "b", "gone", "missing"}); n != 2 {
+		t.Fatalf("expected 2 live entries removed, got %d", n)
+	}
+	if keys := c.Keys(); len(keys) != 1 || keys[0] != "c" {
+		t.Fatalf("expected only c to remain, got %v", keys)
+	}
+	if c.lru.Len() != 1 || len(c.tags) != 0 || c.SizeBytes() != defaultEntrySize {
+		t.Fatalf("bookkeeping out of sync: lru=%d tags=%v bytes=%d", c.lru.Len(), c.tags, c.SizeBytes())
+	}
+}
@@ -0,0 +1,80 @@
+package cache
+
+import "time"
+
+// SetWithDependencies is Set for a value computed from other entries: the
+// entry records the keys in dependsOn, and is invalidated as soon as any of
+// them changes. Storing a parent key in any way (Set, Update, Increment, a
+// refresh...) or removing it in any way (Delete, expiry, eviction, Flush...)
+// removes every entry depending on it, with ReasonDependency, and so on
+// transitively down the graph. The parents need not be present when the
+// dependent is stored. Overwriting the key, with or without dependencies,
+// replaces its previous ones; touching a parent's deadline does not count as a
+// change.
+//
+// Dependency cycles are allowed and broken: a cascade removes each key at most
+// once and never removes the key whose change started it, so storing a key
+// that indirectly depends on itself keeps the new value.
+func (c *Cache) SetWithDependencies(key string, value interface{}, ttl time.Duration, dependsOn ...string) {
+	key = c.normalize(key)
+	e := entry{value: value, expiresAt: c.expiry(c.now(), ttl)}
+	for _, p := range dependsOn {
+		e.parents = append(e.parents, c.normalize(p))
+	}
+	c.mu.Lock()
+	defer c.unlock()
+	c.setLocked(key, e)
+}
+
+// dependLocked records key as a dependent of each parent. c.mu must be held for
+// writing.
+func (c *Cache) dependLocked(key string, parents []string) {
+	for _, p := range parents {
+		keys := c.dependents[p]
+		if keys == nil {
+			if c.dependents == nil {
+				c.dependents = make(map[string]map[string]struct{})
+			}
+			keys = make(map[string]struct{})
+			c.dependents[p] = keys
+		}
+		keys[key] = struct{}{}
+	}
+}
+
+// undependLocked removes key from the dependents of each parent, dropping
+// parents that no longer have any. c.mu must be held for writing.
+func (c *Cache) undependLocked(key string, parents []string) {
+	for _, p := range parents {
+		keys := c.dependents[p]
+		delete(keys, key)
+		if len(keys) == 0 {
+			delete(c.dependents, p)
+		}
+	}
+}
+
+// invalidateDependentsLocked removes the entries depending on key, which has
+// just been stored or removed. Their own dependents follow through
+// removeLocked; c.cascade remembers every key reached since the outermost call
+// so that cycles end. c.mu must be held for writing.
+func (c *Cache) invalidateDependentsLocked(key string) {
+	if len(c.dependents[key]) == 0 {
+		return
+	}
+	outermost := c.cascade == nil
+	if outermost {
+		c.cascade = make(map[string]struct{})
+		defer func() { c.cascade = nil }()
+	}
+	c.cascade[key] = struct{}{}
+	for child := range c.dependents[key] {
+		if _, seen := c.cascade[child]; seen {
+			continue
+		}
+		c.cascade[child] = struct{}{}
+		if e, ok := c.data[child]; ok {
+			c.removeLocked(child, e, ReasonDependency)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSetWithDependenciesCascades(t *testing.T) {
+	c := New(time.Hour)
+	defer c.Stop()
+
+	var removed []string
+	c.OnEvict(func(k string, _ interface{}, r EvictReason) {
+		if r == ReasonDependency {
+			removed = append(removed, k)
+		}
+	})
+
+	c.Set("base", 1, 0)
+	c.SetWithDependencies("derived", 2, 0, "base")
+	c.SetWithDependencies("report", 3, 0, "derived")
+	c.SetWithDependencies("other", 4, 0, "unrelated")
+
+	c.Set("base", 10, 0)
+	for _, k := range []string{"derived", "report"} {
+		if _, ok := c.Get(k); ok {
+			t.Fatalf("expected %s to be invalidated when base changed", k)
+		}
+	}
+	if v, _ := c.Get("base"); v != 10 {
+		t.Fatalf("expected the new base value, got %v", v)
+	}
+	if _, ok := c.Get("other"); !ok {
+		t.Fatalf("expected unrelated dependents to survive")
+	}
+	if len(removed) != 2 {
+		t.Fatalf("expected two dependency invalidations, got %v", removed)
+	}
+
+	c.SetWithDependencies("derived", 2, 0, "base")
+	c.Delete("base")
+	if _, ok := c.Get("derived"); ok {
+		t.Fatalf("expected deleting base to invalidate derived")
+	}
+
+	// Overwriting a dependent drops its old dependencies.
+	c.SetWithDependencies("derived", 2, 0, "base")
+	c.Set("derived", 3, 0)
+	c.Set("base", 1, 0)
+	if v, _ := c.Get("derived"); v != 3 {
+		t.Fatalf("expected a plain Set to clear dependencies, got %v", v)
+	}
+	c.mu.RLock()
+	left := len(c.dependents)
+	c.mu.RUnlock()
+	if left != 1 { // only "unrelated" -> "other"
+		t.Fatalf("expected the dependency index to be cleaned up, got %d parents", left)
+	}
+}
+
+func TestSetWithDependenciesExpiryCascades(t *testing.T) {
+	c := NewManual()
+	defer c.Stop()
+
+	c.Set("base", 1, time.Millisecond)
+	c.SetWithDependencies("derived", 2, 0, "base")
+	time.Sleep(5 * time.Millisecond)
+	c.DeleteExpired()
+	if _, ok := c.Get("derived"); ok {
+		t.Fatalf("expected the expiry of base to invalidate derived")
+	}
+}
+
+func TestSetWithDependenciesBreaksCycles(t *testing.T) {
+	c := New(time.Hour)
+	defer c.Stop()
+
+	c.SetWithDependencies("a", 1, 0, "c")
+	c.SetWithDependencies("b", 2, 0, "a")
+	// Closing the cycle a -> b -> c -> a invalidates a, then b, and stops at c.
+	c.SetWithDependencies("c", 3, 0, "b")
+	if v, ok := c.Get("c"); !ok || v != 3 {
+		t.Fatalf("expected the key that started the cascade to keep its value, got %v", v)
+	}
+	if c.Len() != 1 {
+		t.Fatalf("expected a and b to be invalidated once each, %d entries left", c.Len())
+	}
+
+	c.SetWithDependencies("self", 1, 0, "self")
+	if _, ok := c.Get("self"); !ok {
+		t.Fatalf("expected a self-dependency not to invalidate the value being stored")
+	}
+	c.Delete("self")
+	if _, ok := c.Get("self"); ok {
+		t.Fatalf("expected self to be deleted")
+	}
+}
+
+func TestEvictionCascadingIntoTheWrittenKey(t *testing.T) {
+	c := NewWithMaxBytes(0, 100, func(v interface{}) int64 { return int64(v.(int)) })
+	defer c.Stop()
+
+	c.Set("p", 40, 0)
+	c.SetWithDependencies("k", 40, 0, "p")
+	c.Get("k")
+	// Making room for the larger k evicts p, which invalidates the old k.
+	c.SetWithDependencies("k", 70, 0, "other")
+
+	if v, ok := c.Get("k"); !ok || v != 70 {
+		t.Fatalf("expected the new k to be stored, got %v ok=%v", v, ok)
+	}
+	c.mu.RLock()
+	_, stored := c.data["k"]
+	tracked := c.lru.Len()
+	c.mu.RUnlock()
+	if !stored || tracked != 1 || c.SizeBytes() != 70 {
+		t.Fatalf("expected k alone to be tracked, got stored=%v lru=%d bytes=%d", stored, tracked, c.SizeBytes())
+	}
+}
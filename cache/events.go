@@ -0,0 +1,82 @@
+package cache
+
+// Op identifies the kind of change an Event reports.
+type Op int
+
+const (
+	// OpSet means a value was stored, whether new or overwriting.
+	OpSet Op = iota
+	// OpDelete means an entry was removed by Delete, Flush, capacity eviction
+	// or any other explicit removal.
+	OpDelete
+	// OpExpire means an entry was removed because its TTL passed.
+	OpExpire
+)
+
+// String returns a lower-case name for the operation.
+func (o Op) String() string {
+	switch o {
+	case OpSet:
+		return "set"
+	case OpDelete:
+		return "delete"
+	case OpExpire:
+		return "expire"
+	}
+	return "unknown"
+}
+
+// Event describes one change to the cache. Value is set for OpSet only.
+type Event struct {
+	Key   string
+	Op    Op
+	Value interface{}
+}
+
+// subscriberBuffer is the channel capacity of each subscription.
+const subscriberBuffer = 256
+
+// subscriber is one Subscribe registration.
+type subscriber struct {
+	ch chan Event
+}
+
+// Subscribe returns a channel receiving an Event for every change to the cache,
+// and a function that ends the subscription and closes the channel. The function
+// is safe to call more than once. Each subscriber has its own channel.
+//
+// Events are published in the order the changes happened, after the cache lock
+// is released, and publishing never blocks: a subscriber whose buffer (256
+// events) is full misses the event, which is counted in Stats().DroppedEvents.
+// Subscribers that must not lose events have to keep up with the write rate.
+func (c *Cache) Subscribe() (<-chan Event, func()) {
+	s := &subscriber{ch: make(chan Event, subscriberBuffer)}
+	c.subMu.Lock()
+	c.subs[s] = struct{}{}
+	c.subscribers.Store(int32(len(c.subs)))
+	c.subMu.Unlock()
+
+	return s.ch, func() {
+		c.subMu.Lock()
+		defer c.subMu.Unlock()
+		if _, ok := c.subs[s]; !ok {
+			return
+		}
+		delete(c.subs, s)
+		c.subscribers.Store(int32(len(c.subs)))
+		close(s.ch)
+	}
+}
+
+// publish delivers events to every subscriber without blocking. c.subMu must be held.
+func (c *Cache) publish(events []Event) {
+	for s := range c.subs {
+		for _, ev := range events {
+			select {
+			case s.ch <- ev:
+			default:
+				c.dropped.Add(1)
+			}
+		}
+	}
+}
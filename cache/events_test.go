@@ -0,0 +1,90 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func nextEvent(t *testing.T, ch <-chan Event) Event {
+	t.Helper()
+	select {
+	case ev := <-ch:
+		return ev
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for event")
+	}
+	return Event{}
+}
+
+func TestSubscribeReceivesChanges(t *testing.T) {
+	c := New(5 * time.Millisecond)
+	defer c.Stop()
+
+	ch1, cancel1 := c.Subscribe()
+	ch2, cancel2 := c.Subscribe()
+	defer cancel2()
+
+	c.Set("a", 1, 0)
+	c.Delete("a")
+	c.Set("b", 2, time.Millisecond)
+
+	want := []Event{{Key: "a", Op: OpSet, Value: 1}, {Key: "a", Op: OpDelete}, {Key: "b", Op: OpSet, Value: 2}}
+	for _, ch := range []<-chan Event{ch1, ch2} {
+		for _, w := range want {
+			if ev := nextEvent(t, ch); ev != w {
+				t.Fatalf("want %+v, got %+v", w, ev)
+			}
+		}
+	}
+	if ev := nextEvent(t, ch1); ev.Key != "b" || ev.Op != OpExpire {
+		t.Fatalf("expected expire event for b, got %+v", ev)
+	}
+
+	cancel1()
+	cancel1() // idempotent
+	if _, open := <-ch1; open {
+		t.Fatalf("expected channel to be closed after unsubscribe")
+	}
+}
+
+func TestSubscribeDropsWhenFull(t *testing.T) {
+	c := New(time.Hour)
+	defer c.Stop()
+
+	_, cancel := c.Subscribe()
+	defer cancel()
+	for i := 0; i < subscriberBuffer+10; i++ {
+		c.Set("k", i, 0)
+	}
+	if d := c.Stats().DroppedEvents; d != 10 {
+		t.Fatalf("expected 10 dropped events, got %d", d)
+	}
+}
+
+func TestCapacityEvictionIsDistinguishable(t *testing.T) {
+	c := NewWithCapacity(time.Hour, 1)
+	defer c.Stop()
+
+	var reasons []EvictReason
+	c.OnEvict(func(_ string, _ interface{}, r EvictReason) { reasons = append(reasons, r) })
+	ch, cancel := c.Subscribe()
+	defer cancel()
+
+	c.Set("a", 1, 0)
+	c.Set("b", 2, 0) // evicts a
+	c.Delete("b")
+
+	// Subscribers still see an eviction as OpDelete; OnEvict and Stats tell it apart.
+	want := []Event{{Key: "a", Op: OpSet, Value: 1}, {Key: "a", Op: OpDelete}, {Key: "b", Op: OpSet, Value: 2}, {Key: "b", Op: OpDelete}}
+	for _, w := range want {
+		if ev := nextEvent(t, ch); ev != w {
+			t.Fatalf("want %+v, got %+v", w, ev)
+		}
+	}
+	if len(reasons) != 2 || reasons[0] != ReasonCapacity || reasons[1] != ReasonDeleted {
+		t.Fatalf("expected a capacity eviction then a delete, got %v", reasons)
+	}
+	if s := c.Stats(); s.Evictions != 1 || s.Expirations != 0 {
+		t.Fatalf("expected only the eviction to be counted, got %+v", s)
+	}
+}
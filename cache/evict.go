@@ -0,0 +1,111 @@
+package cache
+
+import "time"
+
+// EvictReason describes why an entry left the cache.
+type EvictReason int
+
+const (
+	// ReasonExpired means the entry's TTL passed. It is reported both by the
+	// cleanup goroutine and by the lazy expiry in Get.
+	ReasonExpired EvictReason = iota
+	// ReasonDeleted means the entry was removed with Delete.
+	ReasonDeleted
+	// ReasonCapacity means the entry was evicted to make room for another.
+	ReasonCapacity
+	// ReasonFlush means the entry was dropped by Flush.
+	ReasonFlush
+	// ReasonDependency means the entry was invalidated because a key it
+	// depends on changed or was removed; see SetWithDependencies.
+	ReasonDependency
+)
+
+// String returns a lower-case name for the reason.
+func (r EvictReason) String() string {
+	switch r {
+	case ReasonExpired:
+		return "expired"
+	case ReasonDeleted:
+		return "deleted"
+	case ReasonCapacity:
+		return "capacity"
+	case ReasonFlush:
+		return "flush"
+	case ReasonDependency:
+		return "dependency"
+	}
+	return "unknown"
+}
+
+// evicted is a removal waiting for its callbacks to run.
+type evicted struct {
+	key       string
+	value     interface{}
+	expiresAt time.Time
+	negative  bool
+	reason    EvictReason
+}
+
+// OnEvict registers fn to be called whenever an entry is removed from the cache.
+// Overwriting a key with Set does not count as a removal. Callbacks may be
+// registered more than once and run in registration order.
+//
+// Callbacks run synchronously, after the cache lock has been released, on the
+// goroutine that triggered the removal: the cleanup goroutine for background
+// expiry, or the caller of Get, Set or Delete otherwise. A slow callback therefore
+// delays that caller (or the next cleanup sweep) but never blocks other cache users.
+// Removals caused by AsyncSet writes run on the goroutine applying them; see
+// AsyncSet for what such callbacks must not do.
+func (c *Cache) OnEvict(fn func(key string, value interface{}, reason EvictReason)) {
+	c.onEvicted(func(ev evicted) { fn(ev.key, ev.value, ev.reason) })
+}
+
+// EvictedItem is one removal reported to an OnEvictBatch callback.
+type EvictedItem struct {
+	Key       string
+	Value     interface{}
+	ExpiresAt time.Time // The entry's deadline; zero if it never expired
+	Reason    EvictReason
+}
+
+// OnEvictBatch registers fn to be called once per group of removals instead of
+// once per entry: a cleanup sweep (or each batch of one configured with
+// WithCleanupBatch), a Flush, a DeletePrefix or any other operation removes its
+// entries under a single lock acquisition, and fn receives all of them in one
+// slice, in removal order. A mass expiry therefore costs one call rather than
+// thousands, and fn can release the resources behind the values together.
+//
+// Batch callbacks run after the per-entry OnEvict callbacks for the same
+// removals, under the same rules: synchronously, after the lock is released, on
+// the goroutine that caused the removals. The slice is shared by every batch
+// callback and must not be retained or modified.
+func (c *Cache) OnEvictBatch(fn func(items []EvictedItem)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onBatch = append(c.onBatch, fn)
+}
+
+// ForEachExpired sets fn as the hook run for each entry a cleanup sweep reaps,
+// whether by the background goroutine or by CleanupNow and DeleteExpired. It is
+// a lighter alternative to OnEvict for work that only concerns expiry, such as
+// deleting the key from a secondary store: entries removed lazily by a read,
+// deleted or evicted for capacity do not reach it. A nil fn removes the hook;
+// a later call replaces it.
+//
+// fn runs synchronously on the sweeping goroutine after the lock is released,
+// so it may call back into the cache, but a slow fn delays the next sweep. For
+// a given sweep (or each batch of one configured with WithCleanupBatch), the
+// OnEvict and OnEvictBatch callbacks run first, then fn for each entry.
+func (c *Cache) ForEachExpired(fn func(key string, value interface{})) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onSwept = fn
+}
+
+// onEvicted registers an eviction callback that sees the whole removal. It is
+// the internal form of OnEvict.
+func (c *Cache) onEvicted(fn func(evicted)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onEvict = append(c.onEvict, fn)
+}
@@ -0,0 +1,157 @@
+package cache
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// evictLog records OnEvict calls.
+type evictLog struct {
+	mu      sync.Mutex
+	reasons map[string]EvictReason
+}
+
+func newEvictLog(c *Cache) *evictLog {
+	l := &evictLog{reasons: make(map[string]EvictReason)}
+	c.OnEvict(func(key string, value interface{}, reason EvictReason) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		l.reasons[key] = reason
+	})
+	return l
+}
+
+func (l *evictLog) reason(key string) (EvictReason, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	r, ok := l.reasons[key]
+	return r, ok
+}
+
+func TestOnEvictReasons(t *testing.T) {
+	c := NewWithCapacity(time.Hour, 2)
+	defer c.Stop()
+	log := newEvictLog(c)
+
+	c.Set("capacity", 1, 0)
+	c.Set("deleted", 2, 0)
+	c.Set("lazy", 3, time.Millisecond) // evicts "capacity"
+	c.Delete("deleted")
+	time.Sleep(5 * time.Millisecond)
+	c.Get("lazy")
+
+	// Background expiry on a cache with a short cleanup interval.
+	bg := New(5 * time.Millisecond)
+	defer bg.Stop()
+	bgLog := newEvictLog(bg)
+	bg.Set("reaped", 4, time.Millisecond)
+	time.Sleep(30 * time.Millisecond)
+
+	want := map[string]EvictReason{
+		"capacity": ReasonCapacity,
+		"deleted":  ReasonDeleted,
+		"lazy":     ReasonExpired,
+	}
+	for key, reason := range want {
+		if got, ok := log.reason(key); !ok || got != reason {
+			t.Errorf("%s: want reason %v, got %v (fired=%v)", key, reason, got, ok)
+		}
+	}
+	if got, ok := bgLog.reason("reaped"); !ok || got != ReasonExpired {
+		t.Errorf("reaped: want reason %v, got %v (fired=%v)", ReasonExpired, got, ok)
+	}
+}
+
+func TestOnEvictMayReenterCache(t *testing.T) {
+	c := New(time.Hour)
+	defer c.Stop()
+
+	done := make(chan struct{})
+	c.OnEvict(func(key string, value interface{}, reason EvictReason) {
+		// Calling back into the cache must not deadlock.
+		c.Set("evicted:"+key, value, 0)
+		close(done)
+	})
+	c.Set("a", 1, 0)
+	c.Delete("a")
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("callback did not run")
+	}
+	if v, ok := c.Get("evicted:a"); !ok || v != 1 {
+		t.Fatalf("expected callback write to land, got %v ok=%v", v, ok)
+	}
+}
+
+func TestOnEvictBatchOncePerSweep(t *testing.T) {
+	c := NewManual()
+	defer c.Stop()
+
+	var batches [][]EvictedItem
+	c.OnEvictBatch(func(items []EvictedItem) {
+		batches = append(batches, append([]EvictedItem(nil), items...))
+	})
+	perEntry := 0
+	c.OnEvict(func(key string, value interface{}, reason EvictReason) { perEntry++ })
+
+	for i := 0; i < 100; i++ {
+		c.Set("k"+strconv.Itoa(i), i, time.Millisecond)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if n := c.CleanupNow(); n != 100 {
+		t.Fatalf("expected 100 expirations, got %d", n)
+	}
+	if len(batches) != 1 || len(batches[0]) != 100 || perEntry != 100 {
+		t.Fatalf("expected one batch of 100 and 100 per-entry calls, got %d batches and %d calls", len(batches), perEntry)
+	}
+	if it := batches[0][0]; it.Reason != ReasonExpired || it.ExpiresAt.IsZero() || it.Value == nil {
+		t.Fatalf("expected a populated expired item, got %+v", it)
+	}
+
+	c.Set("a", 1, 0)
+	c.Delete("a")
+	c.Set("kept", 1, 0)
+	if len(batches) != 2 || len(batches[1]) != 1 || batches[1][0].Key != "a" || batches[1][0].Reason != ReasonDeleted {
+		t.Fatalf("expected a single-item batch for Delete, got %+v", batches[1:])
+	}
+}
+
+func TestForEachExpiredRunsAfterOnEvict(t *testing.T) {
+	c := NewManual()
+	defer c.Stop()
+
+	var order []string
+	c.OnEvict(func(key string, value interface{}, reason EvictReason) {
+		order = append(order, "evict:"+key)
+	})
+	c.ForEachExpired(func(key string, value interface{}) {
+		order = append(order, "expired:"+key)
+		c.Set("reentered", value, 0) // the lock is not held
+	})
+
+	c.Set("a", 1, time.Millisecond)
+	c.Set("lazy", 2, time.Millisecond)
+	c.Set("deleted", 3, 0)
+	c.Delete("deleted")
+	time.Sleep(5 * time.Millisecond)
+	c.Get("lazy")
+	order = order[:0]
+	if n := c.CleanupNow(); n != 1 {
+		t.Fatalf("expected one entry swept, got %d", n)
+	}
+	if len(order) != 2 || order[0] != "evict:a" || order[1] != "expired:a" {
+		t.Fatalf("expected OnEvict then the hook for swept entries only, got %v", order)
+	}
+	if v, ok := c.Get("reentered"); !ok || v != 1 {
+		t.Fatalf("expected the hook to see the value and re-enter the cache, got %v ok=%v", v, ok)
+	}
+
+	c.ForEachExpired(nil)
+	c.Set("b", 1, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	c.CleanupNow()
+}
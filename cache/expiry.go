@@ -0,0 +1,57 @@
+package cache
+
+import (
+	"container/heap"
+	"time"
+)
+
+// expiryNode records that key was due to expire at at. A node goes stale when
+// the entry is deleted or its deadline changes; stale nodes are skipped when
+// popped and discarded wholesale by compaction.
+type expiryNode struct {
+	key string
+	at  time.Time
+}
+
+// expiryHeap is a min-heap of deadlines, implementing heap.Interface.
+type expiryHeap []expiryNode
+
+func (h expiryHeap) Len() int            { return len(h) }
+func (h expiryHeap) Less(i, j int) bool  { return h[i].at.Before(h[j].at) }
+func (h expiryHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *expiryHeap) Push(x interface{}) { *h = append(*h, x.(expiryNode)) }
+func (h *expiryHeap) Pop() interface{} {
+	old := *h
+	n := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return n
+}
+
+// minHeapCompaction is the heap size below which stale nodes are never compacted.
+const minHeapCompaction = 64
+
+// scheduleLocked records a new deadline for key. Zero deadlines never expire
+// and are not tracked. c.mu must be held for writing.
+func (c *Cache) scheduleLocked(key string, at time.Time) {
+	if at.IsZero() {
+		return
+	}
+	heap.Push(&c.expiries, expiryNode{key: key, at: at})
+	if len(c.expiries) > minHeapCompaction && len(c.expiries) > 2*len(c.data) {
+		c.compactExpiriesLocked()
+	}
+}
+
+// compactExpiriesLocked rebuilds the heap from the live deadlines in c.data,
+// dropping stale nodes. It is O(n) but runs only once stale nodes outnumber
+// live entries, so its cost is amortized over the writes that created them.
+func (c *Cache) compactExpiriesLocked() {
+	h := make(expiryHeap, 0, len(c.data))
+	for k, e := range c.data {
+		if !e.expiresAt.IsZero() {
+			h = append(h, expiryNode{key: k, at: e.expiresAt})
+		}
+	}
+	heap.Init(&h)
+	c.expiries = h
+}
@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSweepSkipsStaleHeapNodes(t *testing.T) {
+	c := New(time.Hour)
+	defer c.Stop()
+
+	c.Set("renewed", 1, time.Millisecond)
+	c.Set("renewed", 1, time.Minute) // leaves a stale 1ms node behind
+	c.Set("deleted", 2, time.Millisecond)
+	c.Delete("deleted")
+	c.Set("expired", 3, time.Millisecond)
+	c.Set("forever", 4, 0)
+	time.Sleep(5 * time.Millisecond)
+
+	c.sweep(time.Now())
+	if _, ok := c.Get("renewed"); !ok {
+		t.Fatalf("stale heap node must not remove the renewed entry")
+	}
+	if _, ok := c.Get("forever"); !ok {
+		t.Fatalf("non-expiring entry must survive")
+	}
+	if s := c.Stats(); s.Expirations != 1 {
+		t.Fatalf("expected exactly one expiration, got %+v", s)
+	}
+	if len(c.expiries) != 1 {
+		t.Fatalf("expected only the renewed deadline to remain, got %d nodes", len(c.expiries))
+	}
+}
+
+func TestExpiryHeapCompacts(t *testing.T) {
+	c := New(time.Hour)
+	defer c.Stop()
+
+	for i := 0; i < 1000; i++ {
+		c.Set("k", i, time.Minute) // every overwrite strands the previous node
+	}
+	if n := len(c.expiries); n > minHeapCompaction+1 {
+		t.Fatalf("expected stale nodes to be compacted, heap holds %d", n)
+	}
+}
+
+// BenchmarkSweep measures a sweep that reaps 10 entries from caches of
+// increasing size; with the expiry heap the cost stays flat.
+func BenchmarkSweep(b *testing.B) {
+	for _, size := range []int{1000, 100000} {
+		b.Run(strconv.Itoa(size), func(b *testing.B) {
+			c := New(time.Hour)
+			defer c.Stop()
+			for i := 0; i < size; i++ {
+				c.Set("live"+strconv.Itoa(i), i, time.Hour)
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				past := time.Now()
+				for j := 0; j < 10; j++ {
+					c.Set("exp"+strconv.Itoa(j), j, time.Nanosecond)
+				}
+				b.StartTimer()
+				c.sweep(past.Add(time.Millisecond))
+			}
+		})
+	}
+}
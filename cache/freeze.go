@@ -0,0 +1,39 @@
+package cache
+
+import "errors"
+
+// ErrFrozen is returned by the error-returning write methods (TrySet,
+// Increment, Decrement, Load and LoadReplace) while the cache is frozen.
+var ErrFrozen = errors.New("cache: cache is frozen")
+
+// Freeze makes the cache read-only, for example once it has been warmed from a
+// trusted source at startup, so that stray writes from handler code cannot
+// corrupt it. Until Unfreeze, every method that stores values or deletes
+// entries leaves the cache untouched: Set and the other void writes do nothing,
+// the boolean ones (SetIfAbsent, Replace, CompareAndSwap, Swap) report failure,
+// Update and Merge return nil, the counting deletes return 0, and the methods
+// above return ErrFrozen. Reads work as usual.
+//
+// Expiry is not suspended: entries still expire on schedule and the cleanup
+// goroutine keeps reaping them, as do lazy removals by reads; call PauseCleanup
+// as well to keep expired entries in memory. Touch, RenewIfOwner and sliding
+// renewals only move deadlines and keep working; SetRefreshing refreshes, which
+// store values, are dropped. Resize records a smaller limit without evicting
+// anything until Unfreeze.
+func (c *Cache) Freeze() {
+	c.frozen.Store(true)
+}
+
+// Unfreeze lifts Freeze; writes made while frozen are not replayed. Entries over
+// a limit lowered by Resize while frozen are evicted now.
+func (c *Cache) Unfreeze() {
+	c.mu.Lock()
+	defer c.unlock()
+	c.frozen.Store(false)
+	c.shrinkLocked()
+}
+
+// Frozen reports whether the cache is frozen.
+func (c *Cache) Frozen() bool {
+	return c.frozen.Load()
+}
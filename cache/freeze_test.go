@@ -0,0 +1,84 @@
+package cache
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestFreezeMakesCacheReadOnly(t *testing.T) {
+	c := NewManual()
+	defer c.Stop()
+
+	c.Set("k", 1, 0)
+	c.Set("short", 2, time.Millisecond)
+	var saved bytes.Buffer
+	if err := c.Save(&saved); err != nil {
+		t.Fatal(err)
+	}
+	c.Freeze()
+	if !c.Frozen() {
+		t.Fatalf("expected Frozen to report true")
+	}
+
+	c.Set("k", 10, 0)
+	c.Set("new", 3, 0)
+	c.Delete("k")
+	c.Flush()
+	if c.SetIfAbsent("other", 1, 0) || c.CompareAndSwap("k", 1, 11, 0) || c.DeletePrefix("") != 0 {
+		t.Fatalf("expected conditional writes and deletes to report failure")
+	}
+	if _, had := c.Swap("k", 12, 0); had {
+		t.Fatalf("expected Swap to report failure")
+	}
+	if v, ok := c.GetAndDelete("k"); ok {
+		t.Fatalf("expected GetAndDelete to refuse, got %v", v)
+	}
+	if v := c.Update("k", func(interface{}, bool) (interface{}, bool) { return 13, true }); v != nil {
+		t.Fatalf("expected Update to be refused, got %v", v)
+	}
+	for name, err := range map[string]error{
+		"TrySet":    c.TrySet("k", 14, 0),
+		"Load":      c.Load(bytes.NewReader(saved.Bytes())),
+		"Increment": func() error { _, err := c.Increment("n", 1, 0); return err }(),
+	} {
+		if err != ErrFrozen {
+			t.Fatalf("%s: expected ErrFrozen, got %v", name, err)
+		}
+	}
+	if v, ok := c.Get("k"); !ok || v != 1 {
+		t.Fatalf("expected reads to see the frozen value, got %v ok=%v", v, ok)
+	}
+	if c.Has("new") || c.Has("n") {
+		t.Fatalf("expected no new keys while frozen")
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	if n := c.CleanupNow(); n != 1 {
+		t.Fatalf("expected expiry to keep reaping while frozen, removed %d", n)
+	}
+
+	c.Unfreeze()
+	c.Set("k", 20, 0)
+	if v, _ := c.Get("k"); v != 20 {
+		t.Fatalf("expected writes to resume after Unfreeze, got %v", v)
+	}
+}
+
+func TestResizeWhileFrozenEvictsOnUnfreeze(t *testing.T) {
+	c := NewWithCapacity(0, 3)
+	defer c.Stop()
+	c.Set("a", 1, 0)
+	c.Set("b", 2, 0)
+	c.Set("c", 3, 0)
+
+	c.Freeze()
+	c.Resize(1)
+	if c.Len() != 3 {
+		t.Fatalf("expected Resize to evict nothing while frozen, got %d entries", c.Len())
+	}
+	c.Unfreeze()
+	if c.Len() != 1 || !c.Has("c") {
+		t.Fatalf("expected Unfreeze to trim to the new limit, got %v", c.Keys())
+	}
+}
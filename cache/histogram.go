@@ -0,0 +1,52 @@
+package cache
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// DefaultTTLBuckets are the bucket bounds TTLHistogram uses when given none.
+var DefaultTTLBuckets = []time.Duration{time.Second, time.Minute, time.Hour, 24 * time.Hour}
+
+// TTLHistogram counts live entries by remaining TTL. Each key of the result is
+// a bucket's upper bound and counts the entries whose remaining TTL is at most
+// that bound and above the next smaller one. Entries outliving the largest bound
+// are counted under time.Duration(math.MaxInt64), and entries that never expire
+// under 0. Every bucket is present, even when empty.
+//
+// bounds need not be sorted; with none, DefaultTTLBuckets are used. It is one
+// pass over the cache under the read lock, O(n) but allocation-free per entry,
+// which suits a periodic metrics job.
+func (c *Cache) TTLHistogram(bounds ...time.Duration) map[time.Duration]int {
+	if len(bounds) == 0 {
+		bounds = DefaultTTLBuckets
+	}
+	sorted := append([]time.Duration(nil), bounds...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	counts := make([]int, len(sorted)+1)
+	never := 0
+	now := c.now()
+	c.mu.RLock()
+	for _, e := range c.data {
+		if e.negative || e.expired(now) {
+			continue
+		}
+		if e.expiresAt.IsZero() {
+			never++
+			continue
+		}
+		ttl := e.expiresAt.Sub(now)
+		counts[sort.Search(len(sorted), func(i int) bool { return sorted[i] >= ttl })]++
+	}
+	c.mu.RUnlock()
+
+	hist := make(map[time.Duration]int, len(counts)+1)
+	for i, b := range sorted {
+		hist[b] += counts[i]
+	}
+	hist[time.Duration(math.MaxInt64)] += counts[len(sorted)]
+	hist[0] += never
+	return hist
+}
@@ -0,0 +1,44 @@
+package cache
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestTTLHistogram(t *testing.T) {
+	c := New(time.Hour)
+	defer c.Stop()
+
+	c.Set("ms", 1, 500*time.Millisecond)
+	c.Set("sec", 1, 30*time.Second)
+	c.Set("sec2", 1, 45*time.Second)
+	c.Set("hours", 1, 48*time.Hour)
+	c.Set("forever", 1, 0)
+	c.Set("gone", 1, time.Millisecond)
+	c.SetNegative("miss", time.Minute)
+	time.Sleep(5 * time.Millisecond)
+
+	got := c.TTLHistogram()
+	want := map[time.Duration]int{
+		time.Second:                  1,
+		time.Minute:                  2,
+		time.Hour:                    0,
+		24 * time.Hour:               0,
+		time.Duration(math.MaxInt64): 1,
+		0:                            1,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("want %v, got %v", want, got)
+	}
+	for b, n := range want {
+		if got[b] != n {
+			t.Fatalf("bucket %v: want %d, got %d (%v)", b, n, got[b], got)
+		}
+	}
+
+	custom := c.TTLHistogram(time.Hour, 40*time.Second)
+	if custom[40*time.Second] != 2 || custom[time.Hour] != 1 {
+		t.Fatalf("expected custom bounds to be sorted and applied, got %v", custom)
+	}
+}
@@ -0,0 +1,172 @@
+package cache
+
+import "time"
+
+// NewWithInsertionOrder creates a Cache whose Keys and Range visit entries in the
+// order their keys were first stored, oldest first, instead of Go's randomized
+// map order, for reproducible dumps and stable pagination. Overwriting a key
+// keeps its position; deleting it and storing it again moves it to the end. The
+// order costs a list element per entry and a little work on every insert and
+// removal, so it is opt-in.
+func NewWithInsertionOrder(cleanerInterval time.Duration) *Cache {
+	return NewWithOptions(cleanerInterval, WithInsertionOrder())
+}
+
+// Keys returns a snapshot of the keys of all live entries, skipping negative
+// entries and expired entries the cleaner has not reaped yet. It copies the
+// whole keyset under the read lock, so it is O(n) and meant for diagnostics
+// rather than hot paths. The keys are in insertion order if the cache was
+// created with NewWithInsertionOrder, and in no particular order otherwise.
+func (c *Cache) Keys() []string {
+	now := c.now()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	keys := make([]string, 0, len(c.data))
+	c.eachLocked(func(k string, e entry) bool {
+		if !e.negative && !e.expired(now) {
+			keys = append(keys, k)
+		}
+		return true
+	})
+	return keys
+}
+
+// eachLocked calls fn for every stored entry, in insertion order when the
+// cache keeps one, until fn returns false. c.mu must be held.
+func (c *Cache) eachLocked(fn func(key string, e entry) bool) {
+	if c.order != nil {
+		for el := c.order.Front(); el != nil; el = el.Next() {
+			k := el.Value.(string)
+			if !fn(k, c.data[k]) {
+				return
+			}
+		}
+		return
+	}
+	for k, e := range c.data {
+		if !fn(k, e) {
+			return
+		}
+	}
+}
+
+// Range calls fn for each live entry while holding the read lock, stopping early
+// if fn returns false. Expired and negative entries are skipped. Iteration order
+// is insertion order with NewWithInsertionOrder and unspecified otherwise.
+//
+// Range allocates nothing, but fn runs with the lock held: it must not call Set,
+// Get, Delete or any other method on the same cache (doing so deadlocks on the
+// RWMutex), and it must not retain references past its return.
+func (c *Cache) Range(fn func(key string, value interface{}) bool) {
+	now := c.now()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	c.eachLocked(func(k string, e entry) bool {
+		if e.negative || e.expired(now) {
+			return true
+		}
+		return fn(k, c.copyOut(e.value))
+	})
+}
+
+// Snapshot returns a fresh map of every live key and value, taken under one read
+// lock so it is a consistent view. Expired and negative entries are skipped, as
+// Get would skip them. Mutating the map does not affect the cache; values pass
+// through copy mode as Get's do. It is O(n) and meant for diagnostics such as
+// admin endpoints rather than hot paths.
+func (c *Cache) Snapshot() map[string]interface{} {
+	now := c.now()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	out := make(map[string]interface{}, len(c.data))
+	for k, e := range c.data {
+		if !e.negative && !e.expired(now) {
+			out[k] = c.copyOut(e.value)
+		}
+	}
+	return out
+}
+
+// GetWhere is Snapshot restricted to the keys pred accepts, for pulling out one
+// namespace without copying the whole cache. It runs in one pass under the
+// read lock and touches neither recency nor hit counters. pred runs with the
+// lock held, so it must not call back into the cache.
+func (c *Cache) GetWhere(pred func(key string) bool) map[string]interface{} {
+	now := c.now()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	out := make(map[string]interface{})
+	for k, e := range c.data {
+		if !e.negative && !e.expired(now) && pred(k) {
+			out[k] = c.copyOut(e.value)
+		}
+	}
+	return out
+}
+
+// LRUKeys returns up to n live keys starting from the least recently used, which
+// is the order capacity eviction removes them in unless SetWeighted is in use.
+// MRUKeys is the opposite end of the same list. Both read the recency list
+// under the read lock without reordering it, and return nil for caches without
+// a capacity or size bound, which track no recency. Expired and negative
+// entries are skipped.
+func (c *Cache) LRUKeys(n int) []string {
+	return c.recencyKeys(n, false)
+}
+
+// MRUKeys returns up to n live keys starting from the most recently used; see
+// LRUKeys.
+func (c *Cache) MRUKeys(n int) []string {
+	return c.recencyKeys(n, true)
+}
+
+// recencyKeys walks the recency list from the front (most recent) or the back.
+func (c *Cache) recencyKeys(n int, fromFront bool) []string {
+	if n <= 0 {
+		return nil
+	}
+	now := c.now()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.lru == nil {
+		return nil
+	}
+	keys := make([]string, 0, min(n, c.lru.Len()))
+	el := c.lru.Back()
+	if fromFront {
+		el = c.lru.Front()
+	}
+	for el != nil && len(keys) < n {
+		k := el.Value.(string)
+		if e := c.data[k]; !e.negative && !e.expired(now) {
+			keys = append(keys, k)
+		}
+		if fromFront {
+			el = el.Next()
+		} else {
+			el = el.Prev()
+		}
+	}
+	return keys
+}
+
+// ExpiringWithin returns the keys of live entries due to expire within d from
+// now, for callers that drive their own refresh-ahead loop. Entries that never
+// expire and negative entries are skipped. Like Keys, it is an O(n) scan under
+// the read lock.
+func (c *Cache) ExpiringWithin(d time.Duration) []string {
+	now := c.now()
+	horizon := now.Add(d)
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var keys []string
+	for k, e := range c.data {
+		if e.negative || e.expiresAt.IsZero() || e.expired(now) {
+			continue
+		}
+		if !e.expiresAt.After(horizon) {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
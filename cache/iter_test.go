@@ -0,0 +1,151 @@
+package cache
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestKeysSkipsExpired(t *testing.T) {
+	c := New(time.Hour)
+	defer c.Stop()
+
+	c.Set("a", 1, 0)
+	c.Set("b", 2, time.Minute)
+	c.Set("gone", 3, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	keys := c.Keys()
+	sort.Strings(keys)
+	if len(keys) != 2 || keys[0] != "a" || keys[1] != "b" {
+		t.Fatalf("expected [a b], got %v", keys)
+	}
+}
+
+func TestRangeVisitsLiveEntriesAndStopsEarly(t *testing.T) {
+	c := New(time.Hour)
+	defer c.Stop()
+
+	for i := 0; i < 10; i++ {
+		c.Set(string(rune('a'+i)), i, 0)
+	}
+	c.Set("gone", -1, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	sum, visited := 0, 0
+	c.Range(func(key string, value interface{}) bool {
+		if key == "gone" {
+			t.Errorf("Range visited expired key")
+		}
+		sum += value.(int)
+		visited++
+		return true
+	})
+	if visited != 10 || sum != 45 {
+		t.Fatalf("expected 10 entries summing to 45, got %d summing to %d", visited, sum)
+	}
+
+	visited = 0
+	c.Range(func(string, interface{}) bool {
+		visited++
+		return visited < 3
+	})
+	if visited != 3 {
+		t.Fatalf("expected Range to stop after 3 entries, visited %d", visited)
+	}
+}
+
+func TestSnapshotCopiesLiveEntries(t *testing.T) {
+	c := New(time.Hour)
+	defer c.Stop()
+
+	c.Set("a", 1, 0)
+	c.Set("b", 2, time.Minute)
+	c.Set("gone", 3, time.Millisecond)
+	c.SetNegative("miss", time.Minute)
+	time.Sleep(5 * time.Millisecond)
+
+	snap := c.Snapshot()
+	if len(snap) != 2 || snap["a"] != 1 || snap["b"] != 2 {
+		t.Fatalf("expected {a:1 b:2}, got %v", snap)
+	}
+	snap["a"] = 100
+	delete(snap, "b")
+	if v, _ := c.Get("a"); v != 1 {
+		t.Fatalf("expected cache unaffected by snapshot writes, got %v", v)
+	}
+	if _, ok := c.Get("b"); !ok {
+		t.Fatalf("expected cache unaffected by snapshot deletes")
+	}
+}
+
+func TestLRUAndMRUKeys(t *testing.T) {
+	c := NewWithCapacity(time.Hour, 10)
+	defer c.Stop()
+
+	for _, k := range []string{"a", "b", "c", "d"} {
+		c.Set(k, 1, 0)
+	}
+	c.Get("a") // a becomes the most recent
+
+	if got := c.LRUKeys(2); len(got) != 2 || got[0] != "b" || got[1] != "c" {
+		t.Fatalf("expected [b c], got %v", got)
+	}
+	if got := c.MRUKeys(10); len(got) != 4 || got[0] != "a" || got[3] != "b" {
+		t.Fatalf("expected [a d c b], got %v", got)
+	}
+	// Reading the lists must not reorder them.
+	if got := c.LRUKeys(1); got[0] != "b" {
+		t.Fatalf("expected LRUKeys not to touch recency, got %v", got)
+	}
+
+	plain := New(time.Hour)
+	defer plain.Stop()
+	plain.Set("a", 1, 0)
+	if got := plain.LRUKeys(1); got != nil {
+		t.Fatalf("expected nil without recency tracking, got %v", got)
+	}
+}
+
+func TestExpiringWithin(t *testing.T) {
+	c := New(time.Hour)
+	defer c.Stop()
+
+	c.Set("soon", 1, 10*time.Second)
+	c.Set("sooner", 1, time.Second)
+	c.Set("later", 1, time.Hour)
+	c.Set("forever", 1, 0)
+	c.Set("gone", 1, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	keys := c.ExpiringWithin(time.Minute)
+	sort.Strings(keys)
+	if len(keys) != 2 || keys[0] != "soon" || keys[1] != "sooner" {
+		t.Fatalf("expected [soon sooner], got %v", keys)
+	}
+	if keys := c.ExpiringWithin(0); len(keys) != 0 {
+		t.Fatalf("expected nothing due right now, got %v", keys)
+	}
+}
+
+func TestGetWhere(t *testing.T) {
+	c := NewManual()
+	defer c.Stop()
+
+	c.Set("user:1", 1, 0)
+	c.Set("user:2", 2, 0)
+	c.Set("order:1", 3, 0)
+	c.Set("user:gone", 4, time.Millisecond)
+	c.SetNegative("user:neg", 0)
+	time.Sleep(5 * time.Millisecond)
+
+	got := c.GetWhere(func(key string) bool { return strings.HasPrefix(key, "user:") })
+	if !reflect.DeepEqual(got, map[string]interface{}{"user:1": 1, "user:2": 2}) {
+		t.Fatalf("expected the live user entries only, got %v", got)
+	}
+	if s := c.Stats(); s.Hits != 0 {
+		t.Fatalf("expected GetWhere not to count hits, got %+v", s)
+	}
+}
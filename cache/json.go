@@ -0,0 +1,53 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// jsonEntry is the JSON form of an entry written by MarshalJSON.
+type jsonEntry struct {
+	Value     json.RawMessage `json:"value"`
+	ExpiresAt *time.Time      `json:"expiresAt,omitempty"` // Omitted if the entry never expires
+}
+
+// MarshalJSON implements json.Marshaler for diagnostic dumps: it encodes the
+// live entries as an object mapping each key to its value and expiry time.
+// Expired and negative entries are skipped. Like Save, it copies the entries
+// under the read lock and encodes them after it is released. A value that
+// encoding/json cannot handle, such as a channel or a func, makes MarshalJSON
+// fail with an error naming its key.
+//
+// The output is meant for inspection; there is no matching UnmarshalJSON, since
+// the concrete types of the values are lost. Use Save and Load to persist a cache.
+func (c *Cache) MarshalJSON() ([]byte, error) {
+	now := c.now()
+	type live struct {
+		value     interface{}
+		expiresAt time.Time
+	}
+	c.mu.RLock()
+	entries := make(map[string]live, len(c.data))
+	for k, e := range c.data {
+		if !e.negative && !e.expired(now) {
+			entries[k] = live{value: e.value, expiresAt: e.expiresAt}
+		}
+	}
+	c.mu.RUnlock()
+
+	out := make(map[string]jsonEntry, len(entries))
+	for k, e := range entries {
+		v, err := json.Marshal(e.value)
+		if err != nil {
+			return nil, fmt.Errorf("cache: marshal value of %q: %w", k, err)
+		}
+		je := jsonEntry{Value: v}
+		if !e.expiresAt.IsZero() {
+			at := e.expiresAt
+			je.ExpiresAt = &at
+		}
+		out[k] = je
+	}
+	return json.Marshal(out)
+}
@@ -0,0 +1,45 @@
+package cache
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMarshalJSON(t *testing.T) {
+	c := New(time.Hour)
+	defer c.Stop()
+
+	c.Set("forever", map[string]int{"n": 1}, 0)
+	c.Set("ttl", "v", time.Minute)
+	c.Set("gone", 1, time.Millisecond)
+	c.SetNegative("miss", time.Minute)
+	time.Sleep(5 * time.Millisecond)
+
+	b, err := json.Marshal(c)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got map[string]struct {
+		Value     json.RawMessage `json:"value"`
+		ExpiresAt *time.Time      `json:"expiresAt"`
+	}
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected only live entries, got %s", b)
+	}
+	if string(got["forever"].Value) != `{"n":1}` || got["forever"].ExpiresAt != nil {
+		t.Fatalf("unexpected forever entry in %s", b)
+	}
+	if got["ttl"].ExpiresAt == nil || time.Until(*got["ttl"].ExpiresAt) <= 0 {
+		t.Fatalf("expected a future expiresAt for ttl in %s", b)
+	}
+
+	c.Set("bad", make(chan int), 0)
+	if _, err := json.Marshal(c); err == nil || !strings.Contains(err.Error(), `"bad"`) {
+		t.Fatalf("expected an error naming the unencodable key, got %v", err)
+	}
+}
@@ -0,0 +1,24 @@
+package cache
+
+import "time"
+
+// NewWithKeyFunc creates a Cache that passes every key through fn before using
+// it, so that keys fn maps to the same string share one entry; for example,
+// strings.ToLower makes lookups case-insensitive. Every method taking a key (or
+// a prefix, for DeletePrefix) applies fn, and methods reporting keys back, such
+// as Keys, Range and OnEvict, report the normalized form.
+//
+// fn must be deterministic and cheap, since it runs on every call. Keys it folds
+// together are treated as the same key, so it should only merge keys the caller
+// really considers equal. A nil fn keeps keys as they are, like New.
+func NewWithKeyFunc(cleanerInterval time.Duration, fn func(key string) string) *Cache {
+	return NewWithOptions(cleanerInterval, WithKeyFunc(fn))
+}
+
+// normalize maps a caller's key to the key stored in the cache.
+func (c *Cache) normalize(key string) string {
+	if c.keyFunc == nil {
+		return key
+	}
+	return c.keyFunc(key)
+}
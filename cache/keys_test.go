@@ -0,0 +1,46 @@
+package cache
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestKeyFuncNormalizesKeys(t *testing.T) {
+	c := NewWithKeyFunc(time.Hour, func(k string) string {
+		return strings.ToLower(strings.TrimSpace(k))
+	})
+	defer c.Stop()
+
+	c.Set(" Foo ", 1, 0)
+	if v, ok := c.Get("foo"); !ok || v != 1 {
+		t.Fatalf("expected normalized lookup to hit, got %v ok=%v", v, ok)
+	}
+	if got := c.Keys(); len(got) != 1 || got[0] != "foo" {
+		t.Fatalf("expected one normalized key, got %v", got)
+	}
+	if found := c.GetMulti([]string{"FOO", "bar"}); len(found) != 1 || found["FOO"] != 1 {
+		t.Fatalf("expected GetMulti keyed by the caller's keys, got %v", found)
+	}
+	if _, err := c.GetOrSet("FOO", 0, func() (interface{}, error) {
+		t.Fatalf("loader must not run for a normalized hit")
+		return nil, nil
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	c.Delete("FOO")
+	if c.Has("foo") {
+		t.Fatalf("expected normalized Delete to remove the entry")
+	}
+}
+
+func TestDefaultKeyFuncIsIdentity(t *testing.T) {
+	c := New(time.Hour)
+	defer c.Stop()
+
+	c.Set("Foo", 1, 0)
+	if c.Has("foo") {
+		t.Fatalf("expected keys to stay case-sensitive by default")
+	}
+}
@@ -0,0 +1,243 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// call is an in-flight load shared by every concurrent caller of the same key.
+type call struct {
+	done chan struct{} // Closed once val and err are set
+	val  interface{}
+	err  error
+
+	waiters int                // Callers still waiting on done; guarded by loadMu
+	cancel  context.CancelFunc // Cancels the loader's context; nil for GetOrSet loads
+}
+
+// GetOrSet returns the cached value for key, or calls fn to compute it and stores
+// the result with the given TTL. If fn returns an error, nothing is cached and
+// every waiting caller receives it. A negative entry for key (see SetNegative)
+// short-circuits the load and yields ErrNotFound.
+//
+// Concurrent callers that miss on the same key share a single invocation of fn,
+// while loads for different keys run independently: neither c.mu nor loadMu is
+// held while fn runs. loadMu only guards the map of in-flight loads, which acts
+// as a per-key lock table whose entries are removed as soon as each load
+// finishes, so a slow loader blocks only callers of its own key.
+//
+// A loaded value is stored exactly as Set stores it, so on a bounded cache the
+// eviction victim is removed before the value is inserted, under the same lock,
+// and the cache never holds more than its capacity even transiently.
+//
+// A hit is served exactly as Get serves it: under the read lock (the write lock
+// on a capacity-bounded cache, which tracks recency), without touching loadMu or
+// allocating. Only a genuine miss escalates to the in-flight table.
+func (c *Cache) GetOrSet(key string, ttl time.Duration, fn func() (interface{}, error)) (interface{}, error) {
+	return c.load(key, func() (interface{}, time.Duration, error) {
+		v, err := fn()
+		return v, ttl, err
+	})
+}
+
+// GetOrLoad is GetOrSet for loaders that know how long their value is valid,
+// such as a token carrying its own expiry: fn returns the TTL to store the value
+// with, and a ttl <= 0 means it never expires. Errors, coalescing and negative
+// entries behave as in GetOrSet.
+func (c *Cache) GetOrLoad(key string, fn func() (value interface{}, ttl time.Duration, err error)) (interface{}, error) {
+	return c.load(key, fn)
+}
+
+// load implements the coalesced get-or-compute path. fn returns the value and the
+// TTL to store it with.
+func (c *Cache) load(key string, fn func() (interface{}, time.Duration, error)) (interface{}, error) {
+	key = c.normalize(key)
+	if e, ok := c.get(key, c.now()); ok {
+		return e.result()
+	}
+	return c.loadMissed(key, fn)
+}
+
+// loadMissed runs or joins the load for a key that just missed.
+func (c *Cache) loadMissed(key string, fn func() (interface{}, time.Duration, error)) (interface{}, error) {
+	c.loadMu.Lock()
+	if cl, ok := c.loads[key]; ok {
+		cl.waiters++
+		c.loadMu.Unlock()
+		<-cl.done
+		return cl.val, cl.err
+	}
+	// A load for key may have completed between the miss and loadMu; it stores its
+	// value before unregistering, so check again before starting a new one.
+	if v, ok := c.Peek(key); ok {
+		c.loadMu.Unlock()
+		return v, nil
+	}
+	cl := &call{done: make(chan struct{}), waiters: 1}
+	c.loads[key] = cl
+	c.loadMu.Unlock()
+
+	c.runLoad(key, cl, fn)
+	return cl.val, cl.err
+}
+
+// GetContext is GetOrSet for loaders that honour a context. If ctx is done before
+// the value is available, GetContext returns ctx.Err() immediately while the load
+// carries on for any other callers waiting on the same key.
+//
+// The loader runs on its own goroutine with a context that carries ctx's values
+// but not its deadline or cancellation; that context is cancelled only once every
+// caller waiting on the load has given up. A loader panic is reported to the
+// waiters as an error.
+func (c *Cache) GetContext(ctx context.Context, key string, ttl time.Duration, fn func(context.Context) (interface{}, error)) (interface{}, error) {
+	key = c.normalize(key)
+	if e, ok := c.get(key, c.now()); ok {
+		return e.result()
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	c.loadMu.Lock()
+	cl, ok := c.loads[key]
+	if !ok {
+		if v, ok := c.Peek(key); ok {
+			c.loadMu.Unlock()
+			return v, nil
+		}
+		loadCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		cl = &call{done: make(chan struct{}), cancel: cancel}
+		c.loads[key] = cl
+		go func() {
+			defer func() { _ = recover() }() // Already delivered to waiters by runLoad.
+			defer cancel()
+			c.runLoad(key, cl, func() (interface{}, time.Duration, error) {
+				v, err := fn(loadCtx)
+				return v, ttl, err
+			})
+		}()
+	}
+	cl.waiters++
+	c.loadMu.Unlock()
+
+	select {
+	case <-cl.done:
+		return cl.val, cl.err
+	case <-ctx.Done():
+		c.abandonLoad(key, cl)
+		return nil, ctx.Err()
+	}
+}
+
+// abandonLoad drops one waiter from cl. When the last waiter leaves a cancellable
+// load, the load is cancelled and unregistered so that later callers start afresh.
+func (c *Cache) abandonLoad(key string, cl *call) {
+	c.loadMu.Lock()
+	defer c.loadMu.Unlock()
+	cl.waiters--
+	if cl.waiters > 0 || cl.cancel == nil {
+		return
+	}
+	cl.cancel()
+	if c.loads[key] == cl {
+		delete(c.loads, key)
+	}
+}
+
+// runLoad invokes fn for cl, stores a successful result and wakes the waiters.
+// While the circuit breaker is open fn is not called and cl fails with
+// ErrCircuitOpen. A panicking loader is reported to waiters as an error and then
+// re-panics.
+func (c *Cache) runLoad(key string, cl *call, fn func() (interface{}, time.Duration, error)) {
+	defer func() {
+		if r := recover(); r != nil {
+			cl.val, cl.err = nil, fmt.Errorf("cache: loader for %q panicked: %v", key, r)
+			c.breaker.record(c.now(), cl.err)
+			c.finishLoad(key, cl)
+			panic(r)
+		}
+		c.finishLoad(key, cl)
+	}()
+
+	if cl.err = c.breaker.allow(c.now()); cl.err != nil {
+		return
+	}
+	var ttl time.Duration
+	cl.val, ttl, cl.err = fn()
+	c.breaker.record(c.now(), cl.err)
+	if cl.err == nil {
+		c.Set(key, cl.val, ttl)
+	}
+}
+
+// finishLoad unregisters cl and releases its waiters.
+func (c *Cache) finishLoad(key string, cl *call) {
+	c.logLoadError(key, cl.err)
+	c.loadMu.Lock()
+	if c.loads[key] == cl {
+		delete(c.loads, key)
+	}
+	c.loadMu.Unlock()
+	close(cl.done)
+}
+
+// NewReadThrough creates a Cache that fills misses from loader. When Get misses,
+// the cache calls loader(key); if it reports ok, the value is stored with the
+// returned TTL (never expiring if ttl <= 0) and returned as a hit. If it reports
+// !ok, Get misses and nothing is stored. Concurrent misses for the same key share
+// one loader call, as with GetOrSet. Other lookups (Peek, GetMulti, ...) do not
+// invoke the loader.
+func NewReadThrough(cleanerInterval time.Duration, loader func(key string) (interface{}, time.Duration, bool)) *Cache {
+	return NewWithOptions(cleanerInterval, WithReadThrough(loader))
+}
+
+// readThroughMiss loads key with the read-through loader after a Get miss.
+func (c *Cache) readThroughMiss(key string) (interface{}, bool) {
+	v, err := c.loadMissed(key, func() (interface{}, time.Duration, error) {
+		v, ttl, ok := c.readThrough(key)
+		if !ok {
+			return nil, 0, ErrNotFound
+		}
+		return v, ttl, nil
+	})
+	if err != nil {
+		return nil, false
+	}
+	return v, true
+}
+
+// warmWorkers bounds how many loader calls Warm runs at once.
+const warmWorkers = 8
+
+// Warm preloads keys, for example right after startup, so that first requests
+// do not all miss. It calls loader for each key, on up to warmWorkers goroutines
+// at a time, and stores every value reported ok with the returned TTL (never
+// expiring if ttl <= 0); keys reported !ok are skipped. Warm returns once every
+// key has been tried, reporting how many were stored; writes refused because the
+// cache is frozen or a value is too large are not counted. loader must be safe
+// for concurrent use.
+func (c *Cache) Warm(keys []string, loader func(key string) (interface{}, time.Duration, bool)) int {
+	var loaded atomic.Int64
+	work := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < min(warmWorkers, len(keys)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for key := range work {
+				if v, ttl, ok := loader(key); ok && c.TrySet(key, v, ttl) == nil {
+					loaded.Add(1)
+				}
+			}
+		}()
+	}
+	for _, key := range keys {
+		work <- key
+	}
+	close(work)
+	wg.Wait()
+	return int(loaded.Load())
+}
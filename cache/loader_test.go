@@ -0,0 +1,441 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetOrSetRunsLoaderOnce(t *testing.T) {
+	c := New(time.Second)
+	defer c.Stop()
+
+	var calls int64
+	release := make(chan struct{})
+	fn := func() (interface{}, error) {
+		atomic.AddInt64(&calls, 1)
+		<-release
+		return "loaded", nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([]interface{}, 20)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			v, err := c.GetOrSet("k", time.Minute, fn)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			results[i] = v
+		}(i)
+	}
+	time.Sleep(20 * time.Millisecond) // let callers pile up on the in-flight load
+	close(release)
+	wg.Wait()
+
+	if n := atomic.LoadInt64(&calls); n != 1 {
+		t.Fatalf("expected loader to run once, ran %d times", n)
+	}
+	for i, v := range results {
+		if v != "loaded" {
+			t.Fatalf("caller %d got %v", i, v)
+		}
+	}
+	if v, ok := c.Get("k"); !ok || v != "loaded" {
+		t.Fatalf("expected loaded value to be cached, got %v ok=%v", v, ok)
+	}
+}
+
+func TestGetOrSetErrorNotCached(t *testing.T) {
+	c := New(time.Second)
+	defer c.Stop()
+
+	errBoom := errors.New("boom")
+	_, err := c.GetOrSet("k", time.Minute, func() (interface{}, error) {
+		return nil, errBoom
+	})
+	if !errors.Is(err, errBoom) {
+		t.Fatalf("expected loader error, got %v", err)
+	}
+	if _, ok := c.Get("k"); ok {
+		t.Fatalf("failed load must not be cached")
+	}
+
+	v, err := c.GetOrSet("k", time.Minute, func() (interface{}, error) {
+		return 7, nil
+	})
+	if err != nil || v != 7 {
+		t.Fatalf("expected retry to load 7, got %v err=%v", v, err)
+	}
+	if len(c.loads) != 0 {
+		t.Fatalf("expected no in-flight loads, got %d", len(c.loads))
+	}
+}
+
+func TestGetOrLoadUsesLoaderTTL(t *testing.T) {
+	c := New(time.Hour)
+	defer c.Stop()
+
+	var calls int64
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.GetOrLoad("token", func() (interface{}, time.Duration, error) {
+				atomic.AddInt64(&calls, 1)
+				<-release
+				return "signed", 20 * time.Millisecond, nil
+			})
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if n := atomic.LoadInt64(&calls); n != 1 {
+		t.Fatalf("expected concurrent misses to coalesce, loader ran %d times", n)
+	}
+	if _, ttl, ok := c.GetWithTTL("token"); !ok || ttl <= 0 || ttl > 20*time.Millisecond {
+		t.Fatalf("expected the loader's TTL, got %v ok=%v", ttl, ok)
+	}
+	time.Sleep(25 * time.Millisecond)
+	if _, ok := c.Get("token"); ok {
+		t.Fatalf("expected the entry to expire with the loader's TTL")
+	}
+
+	c.GetOrLoad("forever", func() (interface{}, time.Duration, error) { return 1, 0, nil })
+	if _, ttl, ok := c.GetWithTTL("forever"); !ok || ttl != 0 {
+		t.Fatalf("expected ttl <= 0 to mean no expiry, got %v", ttl)
+	}
+
+	errBoom := errors.New("boom")
+	if _, err := c.GetOrLoad("bad", func() (interface{}, time.Duration, error) {
+		return nil, time.Minute, errBoom
+	}); !errors.Is(err, errBoom) {
+		t.Fatalf("expected loader error, got %v", err)
+	}
+	if c.Has("bad") {
+		t.Fatalf("failed load must not be cached")
+	}
+}
+
+func TestGetOrSetLoadsDistinctKeysInParallel(t *testing.T) {
+	c := New(time.Hour)
+	defer c.Stop()
+
+	const n = 8
+	var started sync.WaitGroup
+	started.Add(n)
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			c.GetOrSet(strconv.Itoa(i), 0, func() (interface{}, error) {
+				started.Done()
+				<-release // held until every loader is running at once
+				return i, nil
+			})
+		}(i)
+	}
+	done := make(chan struct{})
+	go func() { started.Wait(); close(done) }()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("expected loaders for distinct keys to run concurrently")
+	}
+	close(release)
+	wg.Wait()
+
+	c.loadMu.Lock()
+	defer c.loadMu.Unlock()
+	if len(c.loads) != 0 {
+		t.Fatalf("expected finished loads to be unregistered, %d left", len(c.loads))
+	}
+}
+
+func TestWarmLoadsInParallel(t *testing.T) {
+	c := New(time.Hour)
+	defer c.Stop()
+
+	keys := make([]string, 20)
+	for i := range keys {
+		keys[i] = strconv.Itoa(i)
+	}
+	var running, peak int64
+	n := c.Warm(keys, func(key string) (interface{}, time.Duration, bool) {
+		cur := atomic.AddInt64(&running, 1)
+		defer atomic.AddInt64(&running, -1)
+		for {
+			p := atomic.LoadInt64(&peak)
+			if cur <= p || atomic.CompareAndSwapInt64(&peak, p, cur) {
+				break
+			}
+		}
+		time.Sleep(2 * time.Millisecond)
+		i, _ := strconv.Atoi(key)
+		return i, time.Minute, i%4 != 0 // every fourth key is missing upstream
+	})
+	if n != 15 || c.Len() != 15 {
+		t.Fatalf("expected 15 keys loaded, got %d (Len %d)", n, c.Len())
+	}
+	if c.Has("0") || !c.Has("1") {
+		t.Fatalf("expected keys reported !ok to be skipped")
+	}
+	if peak < 2 || peak > warmWorkers {
+		t.Fatalf("expected between 2 and %d concurrent loads, saw %d", warmWorkers, peak)
+	}
+	if c.Warm(nil, nil) != 0 {
+		t.Fatalf("expected warming no keys to load nothing")
+	}
+}
+
+func TestWarmCountsOnlyStoredValues(t *testing.T) {
+	c := NewWithMaxValueSize(0, 3, func(v interface{}) int64 { return int64(len(v.(string))) })
+	defer c.Stop()
+
+	values := map[string]string{"a": "x", "b": "toolong", "c": "yy"}
+	loader := func(key string) (interface{}, time.Duration, bool) { return values[key], 0, true }
+	if n := c.Warm([]string{"a", "b", "c"}, loader); n != 2 || c.Len() != 2 {
+		t.Fatalf("expected the oversized value not to be counted, got %d (Len %d)", n, c.Len())
+	}
+	c.Freeze()
+	if n := c.Warm([]string{"d"}, loader); n != 0 {
+		t.Fatalf("expected nothing stored while frozen, got %d", n)
+	}
+}
+
+func TestGetContextCancelledWaiterDoesNotCancelSharedLoad(t *testing.T) {
+	c := New(time.Second)
+	defer c.Stop()
+
+	release := make(chan struct{})
+	var loaderCancelled int64
+	fn := func(ctx context.Context) (interface{}, error) {
+		select {
+		case <-release:
+			return "v", nil
+		case <-ctx.Done():
+			atomic.StoreInt64(&loaderCancelled, 1)
+			return nil, ctx.Err()
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := c.GetContext(ctx, "k", time.Minute, fn)
+		firstErr <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	second := make(chan interface{}, 1)
+	go func() {
+		v, _ := c.GetContext(context.Background(), "k", time.Minute, fn)
+		second <- v
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	cancel()
+	if err := <-firstErr; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected cancelled waiter to return context.Canceled, got %v", err)
+	}
+	close(release)
+	if v := <-second; v != "v" {
+		t.Fatalf("expected remaining waiter to get the loaded value, got %v", v)
+	}
+	if atomic.LoadInt64(&loaderCancelled) != 0 {
+		t.Fatalf("shared load must not be cancelled while others wait")
+	}
+}
+
+func TestGetContextLastWaiterCancelsLoad(t *testing.T) {
+	c := New(time.Second)
+	defer c.Stop()
+
+	cancelled := make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	_, err := c.GetContext(ctx, "k", time.Minute, func(ctx context.Context) (interface{}, error) {
+		<-ctx.Done()
+		close(cancelled)
+		return nil, ctx.Err()
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("loader context was not cancelled after its only waiter left")
+	}
+	if _, ok := c.Get("k"); ok {
+		t.Fatalf("cancelled load must not be cached")
+	}
+}
+
+func TestReadThroughCoalescesMisses(t *testing.T) {
+	var calls int64
+	release := make(chan struct{})
+	c := NewReadThrough(time.Second, func(key string) (interface{}, time.Duration, bool) {
+		atomic.AddInt64(&calls, 1)
+		if key == "absent" {
+			return nil, 0, false
+		}
+		<-release
+		return "db:" + key, time.Minute, true
+	})
+	defer c.Stop()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, ok := c.Get("user"); !ok || v != "db:user" {
+				t.Errorf("expected loaded value, got %v ok=%v", v, ok)
+			}
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if n := atomic.LoadInt64(&calls); n != 1 {
+		t.Fatalf("expected one loader call, got %d", n)
+	}
+	if _, ttl, ok := c.GetWithTTL("user"); !ok || ttl <= 0 {
+		t.Fatalf("expected value cached with loader TTL, ttl=%v ok=%v", ttl, ok)
+	}
+
+	if _, ok := c.Get("absent"); ok {
+		t.Fatalf("expected miss when loader reports !ok")
+	}
+	if _, ok := c.Peek("absent"); ok {
+		t.Fatalf("nothing should be stored for a !ok load")
+	}
+}
+
+// BenchmarkGetOrSetParallelLoads measures misses on distinct keys whose loader
+// sleeps, as an I/O-bound loader would. Loads overlap, so throughput scales with
+// parallelism instead of being capped at one load per sleep.
+func BenchmarkGetOrSetParallelLoads(b *testing.B) {
+	c := NewManual()
+	defer c.Stop()
+	var n uint64
+	b.SetParallelism(16)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			key := strconv.FormatUint(atomic.AddUint64(&n, 1), 10)
+			c.GetOrSet(key, 0, func() (interface{}, error) {
+				time.Sleep(100 * time.Microsecond)
+				return key, nil
+			})
+		}
+	})
+}
+
+func benchmarkParallelHits(b *testing.B, get func(key string)) {
+	keys := make([]string, 1024)
+	for i := range keys {
+		keys[i] = "key" + strconv.Itoa(i)
+	}
+	var n uint64
+	b.RunParallel(func(pb *testing.PB) {
+		i := atomic.AddUint64(&n, 1) * 7919
+		for pb.Next() {
+			get(keys[i%uint64(len(keys))])
+			i++
+		}
+	})
+}
+
+// BenchmarkGetOrSetHit measures GetOrSet on keys that are always cached. Compare
+// with BenchmarkGetHit, which it should match within noise, allocation-free:
+// the hit path takes the same read lock as Get and never reaches the loader or
+// loadMu.
+func BenchmarkGetOrSetHit(b *testing.B) {
+	c := NewManual()
+	defer c.Stop()
+	for i := 0; i < 1024; i++ {
+		c.Set("key"+strconv.Itoa(i), i, 0)
+	}
+	load := func() (interface{}, error) { panic("loader called on a hit") }
+	b.ResetTimer()
+	benchmarkParallelHits(b, func(key string) { c.GetOrSet(key, 0, load) })
+}
+
+func BenchmarkGetHit(b *testing.B) {
+	c := NewManual()
+	defer c.Stop()
+	for i := 0; i < 1024; i++ {
+		c.Set("key"+strconv.Itoa(i), i, 0)
+	}
+	b.ResetTimer()
+	benchmarkParallelHits(b, func(key string) { c.Get(key) })
+}
+
+func TestGetOrSetRespectsCapacity(t *testing.T) {
+	const capacity = 16
+	c := NewWithCapacity(0, capacity)
+	defer c.Stop()
+
+	stop := make(chan struct{})
+	var over atomic.Int64
+	var watch sync.WaitGroup
+	watch.Add(1)
+	go func() {
+		defer watch.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			if n := c.Len(); n > capacity {
+				over.Store(int64(n))
+			}
+			runtime.Gosched()
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				key := strconv.Itoa(g) + "-" + strconv.Itoa(i)
+				if _, err := c.GetOrSet(key, 0, func() (interface{}, error) { return i, nil }); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	close(stop)
+	watch.Wait()
+
+	if n := over.Load(); n != 0 {
+		t.Fatalf("Len exceeded the capacity of %d: saw %d", capacity, n)
+	}
+	if n := c.Len(); n != capacity {
+		t.Fatalf("expected the cache to end full at %d, got %d", capacity, n)
+	}
+	if s := c.Stats(); s.Evictions != 8*200-capacity {
+		t.Fatalf("expected every load beyond capacity to evict, got %d evictions", s.Evictions)
+	}
+}
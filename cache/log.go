@@ -0,0 +1,51 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// NewWithLogger creates a Cache that reports its activity to logger at debug
+// level; see WithLogger.
+func NewWithLogger(cleanerInterval time.Duration, logger *slog.Logger) *Cache {
+	return NewWithOptions(cleanerInterval, WithLogger(logger))
+}
+
+// WithLogger makes the cache log, at slog.LevelDebug, every removal with its
+// reason, a summary of each cleanup sweep that found work, and every loader
+// error other than ErrNotFound. Records are written after the cache lock has
+// been released, on the goroutine that caused them, so a slow handler delays
+// only that goroutine. A nil logger, the default, logs nothing.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Cache) {
+		if logger == nil {
+			return
+		}
+		c.logger = logger
+		c.onEvicted(func(ev evicted) {
+			logger.LogAttrs(context.Background(), slog.LevelDebug, "cache: entry removed",
+				slog.String("key", ev.key), slog.String("reason", ev.reason.String()))
+		})
+	}
+}
+
+// logSweep logs the outcome of a cleanup sweep that examined at least one
+// deadline. It must be called without c.mu held.
+func (c *Cache) logSweep(took time.Duration, scanned, removed int) {
+	if c.logger == nil || scanned == 0 {
+		return
+	}
+	c.logger.LogAttrs(context.Background(), slog.LevelDebug, "cache: cleanup sweep",
+		slog.Int("scanned", scanned), slog.Int("removed", removed), slog.Duration("took", took))
+}
+
+// logLoadError logs a failed load of key. It must be called without c.mu held.
+func (c *Cache) logLoadError(key string, err error) {
+	if c.logger == nil || err == nil || errors.Is(err, ErrNotFound) {
+		return
+	}
+	c.logger.LogAttrs(context.Background(), slog.LevelDebug, "cache: load failed",
+		slog.String("key", key), slog.Any("error", err))
+}
@@ -0,0 +1,51 @@
+package cache
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoggerReportsRemovalsSweepsAndLoadErrors(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	c := NewWithLogger(0, logger)
+	defer c.Stop()
+
+	c.Set("gone", 1, time.Millisecond)
+	c.Set("kept", 2, 0)
+	c.Delete("kept")
+	time.Sleep(5 * time.Millisecond)
+	c.CleanupNow()
+	c.GetOrSet("bad", 0, func() (interface{}, error) { return nil, errors.New("backend down") })
+	c.SetNegative("neg", 0)
+	c.GetOrSet("neg", 0, func() (interface{}, error) { return 1, nil })
+
+	out := buf.String()
+	for _, want := range []string{
+		`msg="cache: entry removed" key=kept reason=deleted`,
+		`msg="cache: entry removed" key=gone reason=expired`,
+		`msg="cache: cleanup sweep" scanned=1 removed=1`,
+		`msg="cache: load failed" key=bad error="backend down"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected log to contain %s, got:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "key=neg") {
+		t.Errorf("expected a cached negative result not to be logged, got:\n%s", out)
+	}
+}
+
+func TestNilLoggerLogsNothing(t *testing.T) {
+	c := NewWithLogger(0, nil)
+	defer c.Stop()
+	c.Set("k", 1, 0)
+	c.Delete("k")
+	if c.logger != nil || len(c.onEvict) != 0 {
+		t.Fatalf("expected no logger to be installed")
+	}
+}
@@ -0,0 +1,104 @@
+package cache
+
+import "time"
+
+// EntryMeta describes the lifecycle of an entry.
+type EntryMeta struct {
+	CreatedAt      time.Time // When the current value was stored
+	LastAccessedAt time.Time // Last Get-style hit; zero if never read
+	ExpiresAt      time.Time // Zero if the entry never expires
+	Weight         int       // Eviction weight; see SetWeighted
+	Priority       Priority  // Pinned for SetWithPriority(..., Pinned), else Normal
+}
+
+// EntryState is the outcome of a GetDetailed lookup. The zero value is
+// StateMiss, so that an unset state never reads as a hit.
+type EntryState int
+
+const (
+	// StateMiss means the key was never stored, was removed, or holds a
+	// negative entry.
+	StateMiss EntryState = iota
+	// StateHit means the key held a live entry.
+	StateHit
+	// StateExpired means the key held an entry whose TTL had passed; the
+	// lookup reaped it.
+	StateExpired
+)
+
+// String returns a lower-case name for the state.
+func (s EntryState) String() string {
+	switch s {
+	case StateMiss:
+		return "miss"
+	case StateHit:
+		return "hit"
+	case StateExpired:
+		return "expired"
+	}
+	return "unknown"
+}
+
+// GetDetailed is Get, but instead of a bare ok it reports why a lookup missed:
+// StateMiss for a key that is not there and StateExpired for one that had
+// expired, which is deleted lazily exactly as Get would. The value is nil
+// unless the state is StateHit. With a read-through loader configured, a
+// successful load turns either miss into StateHit.
+func (c *Cache) GetDetailed(key string) (interface{}, EntryState) {
+	key = c.normalize(key)
+	e, state := c.getState(key, c.now())
+	if state != StateHit {
+		if c.readThrough != nil {
+			if v, ok := c.readThroughMiss(key); ok {
+				return v, StateHit
+			}
+		}
+		return nil, state
+	}
+	if e.negative {
+		return nil, StateMiss
+	}
+	return e.value, StateHit
+}
+
+// GetMeta returns the timestamps of a live entry without reading its value or
+// counting as an access; a negative entry reports false, as it misses for Get.
+// Every write that stores a value, including Update, Merge and Increment,
+// resets both CreatedAt and LastAccessedAt; TTL changes such as Touch do not.
+//
+// Last-access times are kept in a per-entry atomic rather than in the map, so
+// recording them costs Get a single atomic store and no write lock.
+func (c *Cache) GetMeta(key string) (EntryMeta, bool) {
+	key = c.normalize(key)
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	e, ok := c.data[key]
+	if !ok || e.negative || e.expired(c.now()) {
+		return EntryMeta{}, false
+	}
+	m := EntryMeta{CreatedAt: e.createdAt, ExpiresAt: e.expiresAt, Weight: e.weight}
+	if e.weight == pinnedWeight {
+		m.Priority = Pinned
+	}
+	if ns := e.accessed.Load(); ns != 0 {
+		m.LastAccessedAt = time.Unix(0, ns)
+	}
+	return m, true
+}
+
+// GetFresh is Get for callers with their own freshness requirement: it returns
+// the value only if it was stored at most maxAge ago, judged by CreatedAt, and
+// otherwise reports a miss. An entry that is too old is left in place for
+// callers with laxer requirements, and does not count as an access.
+func (c *Cache) GetFresh(key string, maxAge time.Duration) (interface{}, bool) {
+	now := c.now()
+	nk := c.normalize(key)
+	c.mu.RLock()
+	e, ok := c.data[nk]
+	c.mu.RUnlock()
+	if ok && now.Sub(e.createdAt) > maxAge {
+		c.misses.Add(1)
+		return nil, false
+	}
+	return c.Get(key)
+}
@@ -0,0 +1,129 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/UtkrushtApps/go-concurrent-cache-answers/cache/cachetest"
+)
+
+func TestGetMeta(t *testing.T) {
+	c := New(time.Hour)
+	defer c.Stop()
+
+	before := time.Now()
+	c.Set("k", 1, time.Minute)
+	m, ok := c.GetMeta("k")
+	if !ok {
+		t.Fatalf("expected meta for live entry")
+	}
+	if m.CreatedAt.Before(before) || !m.LastAccessedAt.IsZero() {
+		t.Fatalf("unexpected meta before any read: %+v", m)
+	}
+	if m.ExpiresAt.Sub(m.CreatedAt) > time.Minute || m.ExpiresAt.IsZero() {
+		t.Fatalf("unexpected expiry %v for createdAt %v", m.ExpiresAt, m.CreatedAt)
+	}
+
+	time.Sleep(2 * time.Millisecond)
+	c.Get("k")
+	m2, _ := c.GetMeta("k")
+	if !m2.LastAccessedAt.After(m.CreatedAt) {
+		t.Fatalf("expected Get to record an access, got %+v", m2)
+	}
+	c.Touch("k", time.Hour)
+	if m3, _ := c.GetMeta("k"); !m3.CreatedAt.Equal(m.CreatedAt) || !m3.LastAccessedAt.Equal(m2.LastAccessedAt) {
+		t.Fatalf("Touch must not reset timestamps: %+v vs %+v", m3, m2)
+	}
+
+	c.Set("k", 2, 0)
+	if m4, _ := c.GetMeta("k"); !m4.CreatedAt.After(m.CreatedAt) || !m4.LastAccessedAt.IsZero() {
+		t.Fatalf("overwrite must reset timestamps, got %+v", m4)
+	}
+	if _, ok := c.GetMeta("missing"); ok {
+		t.Fatalf("expected no meta for missing key")
+	}
+	c.SetNegative("neg", 0)
+	if _, ok := c.GetMeta("neg"); ok {
+		t.Fatalf("expected no meta for a negative entry")
+	}
+}
+
+func TestGetFreshHonoursMaxAge(t *testing.T) {
+	c := New(time.Hour)
+	defer c.Stop()
+
+	c.Set("k", 1, 0)
+	if v, ok := c.GetFresh("k", time.Minute); !ok || v != 1 {
+		t.Fatalf("expected a young entry to be served, got %v ok=%v", v, ok)
+	}
+	time.Sleep(15 * time.Millisecond)
+	if _, ok := c.GetFresh("k", 10*time.Millisecond); ok {
+		t.Fatalf("expected an entry older than maxAge to miss")
+	}
+	if v, ok := c.Get("k"); !ok || v != 1 {
+		t.Fatalf("expected the old entry to stay for other callers, got %v ok=%v", v, ok)
+	}
+	c.Set("k", 2, 0)
+	if v, ok := c.GetFresh("k", 10*time.Millisecond); !ok || v != 2 {
+		t.Fatalf("expected an overwrite to reset the age, got %v ok=%v", v, ok)
+	}
+}
+
+func TestInPlaceWritesResetAge(t *testing.T) {
+	clock := cachetest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	c := NewWithClock(0, clock)
+	defer c.Stop()
+
+	writes := map[string]func(){
+		"update":    func() { c.Update("update", func(interface{}, bool) (interface{}, bool) { return 2, true }) },
+		"merge":     func() { c.Merge("merge", 2, func(old, new interface{}) interface{} { return new }, 0) },
+		"increment": func() { c.Increment("increment", 1, 0) },
+	}
+	for k, write := range writes {
+		c.Set(k, int64(1), 0)
+		c.Get(k)
+		clock.Advance(time.Minute)
+		write()
+		if m, _ := c.GetMeta(k); !m.CreatedAt.Equal(clock.Now()) || !m.LastAccessedAt.IsZero() {
+			t.Fatalf("%s: expected CreatedAt and LastAccessedAt to start over, got %+v", k, m)
+		}
+		if _, ok := c.GetFresh(k, time.Second); !ok {
+			t.Fatalf("%s: expected the rewritten value to be fresh", k)
+		}
+	}
+}
+
+func TestGetDetailedDistinguishesExpiredFromMissing(t *testing.T) {
+	for _, capacity := range []int{0, 10} {
+		c := NewWithCapacity(time.Hour, capacity)
+
+		c.Set("live", 1, 0)
+		c.Set("short", 2, time.Millisecond)
+		c.SetNegative("neg", time.Minute)
+		time.Sleep(5 * time.Millisecond)
+
+		if v, st := c.GetDetailed("live"); st != StateHit || v != 1 {
+			t.Fatalf("capacity=%d: expected a hit on live, got %v %v", capacity, v, st)
+		}
+		if v, st := c.GetDetailed("short"); st != StateExpired || v != nil {
+			t.Fatalf("capacity=%d: expected short to be expired, got %v %v", capacity, v, st)
+		}
+		if _, st := c.GetDetailed("short"); st != StateMiss {
+			t.Fatalf("capacity=%d: expected the expired entry to be reaped, got %v", capacity, st)
+		}
+		for _, k := range []string{"absent", "neg"} {
+			if v, st := c.GetDetailed(k); st != StateMiss || v != nil {
+				t.Fatalf("capacity=%d: expected a miss on %s, got %v %v", capacity, k, v, st)
+			}
+		}
+		if s := c.Stats(); s.Expirations != 1 {
+			t.Fatalf("capacity=%d: expected one lazy expiration, got %+v", capacity, s)
+		}
+		c.Stop()
+	}
+
+	var zero EntryState
+	if zero != StateMiss || zero.String() != "miss" {
+		t.Fatalf("expected the zero EntryState to be a miss, got %v", zero)
+	}
+}
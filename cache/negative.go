@@ -0,0 +1,73 @@
+package cache
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrNotFound is returned by the loader methods when key holds a cached
+// negative result without an error of its own; see SetError.
+var ErrNotFound = errors.New("cache: cached negative result")
+
+// SetNegative caches the fact that key does not exist in the backing store, for
+// ttl (or forever if ttl <= 0). While the negative entry lives, Get reports a
+// miss without calling a read-through loader, GetOrSet and GetContext return
+// ErrNotFound without calling theirs, and Lookup reports negative=true. Negative
+// entries expire and are reaped like any other; a later Set replaces them.
+func (c *Cache) SetNegative(key string, ttl time.Duration) {
+	key = c.normalize(key)
+	e := entry{expiresAt: c.expiry(c.now(), ttl), negative: true}
+	c.mu.Lock()
+	defer c.unlock()
+	c.setLocked(key, e)
+}
+
+// SetError caches err as the outcome of looking key up, for ttl (or forever if
+// ttl <= 0), so that a failure known not to change soon, such as a permission
+// error from the backing store, is not retried on every request. The entry is
+// a negative one: Get reports a miss, Lookup reports negative=true, and
+// GetOrError, GetOrSet and GetContext return err without calling their loader.
+// It expires and is reaped like any other entry. A nil err is SetNegative.
+func (c *Cache) SetError(key string, err error, ttl time.Duration) {
+	key = c.normalize(key)
+	e := entry{expiresAt: c.expiry(c.now(), ttl), negative: true, err: err}
+	c.mu.Lock()
+	defer c.unlock()
+	c.setLocked(key, e)
+}
+
+// GetOrError returns what is cached for key: its value, or the error stored by
+// SetError (ErrNotFound for a SetNegative entry). ok reports whether key has a
+// live entry of either kind.
+func (c *Cache) GetOrError(key string) (value interface{}, err error, ok bool) {
+	key = c.normalize(key)
+	e, ok := c.get(key, c.now())
+	if !ok {
+		return nil, nil, false
+	}
+	value, err = e.result()
+	return value, err, true
+}
+
+// Lookup is Get that tells a cached negative result apart from an uncached miss.
+// ok reports whether key has a live entry of either kind; negative reports that
+// the entry is a negative one, in which case value is nil.
+func (c *Cache) Lookup(key string) (value interface{}, negative bool, ok bool) {
+	key = c.normalize(key)
+	e, ok := c.get(key, c.now())
+	if !ok {
+		return nil, false, false
+	}
+	return e.value, e.negative, true
+}
+
+// result returns the entry as a loader result.
+func (e entry) result() (interface{}, error) {
+	if e.negative {
+		if e.err != nil {
+			return nil, e.err
+		}
+		return nil, ErrNotFound
+	}
+	return e.value, nil
+}
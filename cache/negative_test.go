@@ -0,0 +1,115 @@
+package cache
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNegativeEntries(t *testing.T) {
+	c := New(5 * time.Millisecond)
+	defer c.Stop()
+
+	c.SetNegative("ghost", 20*time.Millisecond)
+	if v, ok := c.Get("ghost"); ok || v != nil {
+		t.Fatalf("Get must report a miss for a negative entry, got %v ok=%v", v, ok)
+	}
+	if _, negative, ok := c.Lookup("ghost"); !ok || !negative {
+		t.Fatalf("Lookup must report a cached negative, negative=%v ok=%v", negative, ok)
+	}
+	if _, negative, ok := c.Lookup("unknown"); ok || negative {
+		t.Fatalf("Lookup must report an uncached miss, negative=%v ok=%v", negative, ok)
+	}
+
+	called := false
+	_, err := c.GetOrSet("ghost", time.Minute, func() (interface{}, error) {
+		called = true
+		return "x", nil
+	})
+	if !errors.Is(err, ErrNotFound) || called {
+		t.Fatalf("expected ErrNotFound without loading, got err=%v called=%v", err, called)
+	}
+
+	time.Sleep(40 * time.Millisecond)
+	c.mu.RLock()
+	_, present := c.data["ghost"]
+	c.mu.RUnlock()
+	if present {
+		t.Fatalf("expected negative entry to be reaped")
+	}
+}
+
+func TestNegativeEntryShieldsReadThrough(t *testing.T) {
+	var calls int64
+	c := NewReadThrough(time.Hour, func(key string) (interface{}, time.Duration, bool) {
+		atomic.AddInt64(&calls, 1)
+		return nil, 0, false
+	})
+	defer c.Stop()
+
+	c.Get("missing")
+	c.SetNegative("missing", time.Minute)
+	for i := 0; i < 5; i++ {
+		c.Get("missing")
+	}
+	if n := atomic.LoadInt64(&calls); n != 1 {
+		t.Fatalf("expected loader to be shielded by the negative entry, got %d calls", n)
+	}
+}
+
+func TestSetErrorReplaysError(t *testing.T) {
+	c := New(5 * time.Millisecond)
+	defer c.Stop()
+
+	errForbidden := errors.New("403 forbidden")
+	c.SetError("k", errForbidden, 20*time.Millisecond)
+
+	if v, err, ok := c.GetOrError("k"); !ok || v != nil || !errors.Is(err, errForbidden) {
+		t.Fatalf("expected the cached error, got %v %v ok=%v", v, err, ok)
+	}
+	if _, ok := c.Get("k"); ok {
+		t.Fatalf("expected Get to treat an error entry as a miss")
+	}
+	if _, err := c.GetOrSet("k", 0, func() (interface{}, error) {
+		t.Fatalf("loader must not run while the error is cached")
+		return nil, nil
+	}); !errors.Is(err, errForbidden) {
+		t.Fatalf("expected GetOrSet to replay the error, got %v", err)
+	}
+
+	c.SetError("nf", nil, time.Minute)
+	if _, err, _ := c.GetOrError("nf"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected a nil error to mean ErrNotFound, got %v", err)
+	}
+	c.Set("v", 1, 0)
+	if v, err, ok := c.GetOrError("v"); !ok || err != nil || v != 1 {
+		t.Fatalf("expected a plain value, got %v %v ok=%v", v, err, ok)
+	}
+
+	time.Sleep(40 * time.Millisecond)
+	c.mu.RLock()
+	_, present := c.data["k"]
+	c.mu.RUnlock()
+	if present {
+		t.Fatalf("expected the cleaner to reap the expired error entry")
+	}
+	if _, _, ok := c.GetOrError("k"); ok {
+		t.Fatalf("expected the error entry to be gone after its TTL")
+	}
+}
+
+func TestNegativeEntriesCountAsMisses(t *testing.T) {
+	for _, capacity := range []int{0, 10} {
+		c := NewWithCapacity(time.Hour, capacity)
+		c.SetNegative("neg", time.Minute)
+
+		c.Get("neg")
+		c.GetMulti([]string{"neg"})
+		c.GetBatch([]string{"neg"})
+		if s := c.Stats(); s.Hits != 0 || s.Misses != 3 {
+			t.Fatalf("capacity=%d: expected every read of a negative entry to miss, got %+v", capacity, s)
+		}
+		c.Stop()
+	}
+}
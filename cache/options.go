@@ -0,0 +1,167 @@
+package cache
+
+import (
+	"container/list"
+	"math"
+	"time"
+)
+
+// Option configures a Cache created with NewWithOptions. The NewWithX
+// constructors are shorthands for a single option each; NewWithOptions lets
+// several be combined.
+type Option func(*Cache)
+
+// NewWithOptions creates a Cache configured by opts and starts its cleanup
+// goroutine, as New does. Options are applied in order.
+func NewWithOptions(cleanerInterval time.Duration, opts ...Option) *Cache {
+	c := newCache(cleanerInterval)
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.start()
+	return c
+}
+
+// WithCapacity bounds the cache to maxEntries entries with LRU eviction; see
+// NewWithCapacity. A maxEntries <= 0 leaves the cache unbounded.
+func WithCapacity(maxEntries int) Option {
+	return func(c *Cache) {
+		if maxEntries > 0 {
+			c.maxEntries = maxEntries
+			c.ensureLRU()
+		}
+	}
+}
+
+// WithMaxBytes bounds the estimated size of the cache; see NewWithMaxBytes.
+func WithMaxBytes(maxBytes int64, sizer func(value interface{}) int64) Option {
+	return func(c *Cache) {
+		c.sizer = sizer
+		if maxBytes > 0 {
+			c.maxBytes = maxBytes
+			c.ensureLRU()
+		}
+	}
+}
+
+// WithMaxValueSize rejects values larger than maxValueBytes; see
+// NewWithMaxValueSize. A nil sizer, or one given while a sizer is already
+// configured by an earlier option, leaves the configured sizer in place.
+func WithMaxValueSize(maxValueBytes int64, sizer func(value interface{}) int64) Option {
+	return func(c *Cache) {
+		if sizer != nil && c.sizer == nil {
+			c.sizer = sizer
+		}
+		c.maxValueSize = maxValueBytes
+	}
+}
+
+// WithInitialCapacity pre-sizes the cache's map; see NewWithSize.
+func WithInitialCapacity(initialCapacity int) Option {
+	return func(c *Cache) {
+		if initialCapacity > 0 {
+			c.sizeHint = initialCapacity
+			c.data = make(map[string]entry, initialCapacity)
+		}
+	}
+}
+
+// WithJitter randomizes each cleanup delay by up to ±jitterFraction of the
+// interval; see NewWithJitter.
+func WithJitter(jitterFraction float64) Option {
+	return func(c *Cache) {
+		c.jitter = math.Min(math.Max(jitterFraction, 0), 1)
+	}
+}
+
+// WithTTLJitter randomizes each positive TTL by up to ±fraction of itself; see
+// NewWithTTLJitter.
+func WithTTLJitter(fraction float64) Option {
+	return func(c *Cache) {
+		c.ttlJitter = math.Min(math.Max(fraction, 0), 1)
+	}
+}
+
+// WithCopy enables defensive copying of values; see NewWithCopy.
+func WithCopy() Option {
+	return func(c *Cache) {
+		c.copyValues = true
+	}
+}
+
+// WithReadThrough fills Get misses from loader; see NewReadThrough.
+func WithReadThrough(loader func(key string) (interface{}, time.Duration, bool)) Option {
+	return func(c *Cache) {
+		c.readThrough = loader
+	}
+}
+
+// WithCleanupBatch bounds how much work a cleanup sweep does per write-lock
+// acquisition: at most batchSize expiry deadlines are examined before the lock
+// is released. The background goroutine handles one batch per tick and resumes
+// on the next, so a large expiry backlog is reaped over several ticks instead of
+// stalling readers and writers in one long sweep; CleanupNow processes batches
+// until the backlog is gone. A batchSize <= 0 means no bound.
+func WithCleanupBatch(batchSize int) Option {
+	return func(c *Cache) {
+		c.cleanupBatch = batchSize
+	}
+}
+
+// WithPolicy replaces the built-in LRU eviction with policy; see NewWithPolicy.
+func WithPolicy(policy EvictionPolicy) Option {
+	return func(c *Cache) {
+		if policy == nil {
+			return
+		}
+		c.policy = policy
+		c.lru = nil
+		c.tracked.Store(true)
+	}
+}
+
+// WithDefaultTTL sets the TTL used by SetDefault; see NewWithDefaultTTL.
+func WithDefaultTTL(defaultTTL time.Duration) Option {
+	return func(c *Cache) {
+		c.defaultTTL = defaultTTL
+	}
+}
+
+// WithMaxTTL caps every entry's lifetime at maxTTL; see NewWithMaxTTL.
+func WithMaxTTL(maxTTL time.Duration) Option {
+	return func(c *Cache) {
+		c.maxTTL = maxTTL
+	}
+}
+
+// WithInsertionOrder makes Keys and Range follow insertion order; see
+// NewWithInsertionOrder.
+func WithInsertionOrder() Option {
+	return func(c *Cache) {
+		c.order = list.New()
+	}
+}
+
+// WithKeyFunc normalizes every key with fn; see NewWithKeyFunc.
+func WithKeyFunc(fn func(key string) string) Option {
+	return func(c *Cache) {
+		c.keyFunc = fn
+	}
+}
+
+// WithClock makes the cache read the time from clock; see NewWithClock.
+func WithClock(clock Clock) Option {
+	return func(c *Cache) {
+		c.clock = clock
+	}
+}
+
+// ensureLRU allocates the recency list used for capacity eviction, unless an
+// eviction policy takes its place. c.mu must be held for writing once the cache
+// is in use.
+func (c *Cache) ensureLRU() {
+	if c.lru == nil && c.policy == nil {
+		c.lru = list.New()
+		c.tracked.Store(true)
+	}
+}
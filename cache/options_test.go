@@ -0,0 +1,51 @@
+package cache
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestOptionsCombine(t *testing.T) {
+	c := NewWithOptions(time.Hour, WithCapacity(2), WithCopy())
+	defer c.Stop()
+
+	s := []int{1}
+	c.Set("a", s, 0)
+	s[0] = 2
+	c.Set("b", 2, 0)
+	c.Set("c", 3, 0)
+	if c.Len() != 2 {
+		t.Fatalf("expected capacity to apply, got %d entries", c.Len())
+	}
+	c.Set("a", s, 0)
+	s[0] = 3
+	if v, _ := c.Get("a"); v.([]int)[0] != 2 {
+		t.Fatalf("expected copy mode to apply, got %v", v)
+	}
+}
+
+func TestCleanupBatchBoundsEachTick(t *testing.T) {
+	c := NewWithOptions(0, WithCleanupBatch(10))
+	defer c.Stop()
+
+	for i := 0; i < 25; i++ {
+		c.Set("k"+strconv.Itoa(i), i, time.Millisecond)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	now := time.Now()
+	for _, want := range []int{10, 10, 5, 0} {
+		if n := c.backgroundSweep(now); n != want {
+			t.Fatalf("expected a tick to reap %d entries, reaped %d", want, n)
+		}
+	}
+
+	for i := 0; i < 25; i++ {
+		c.Set("k"+strconv.Itoa(i), i, time.Millisecond)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if n := c.CleanupNow(); n != 25 {
+		t.Fatalf("expected CleanupNow to work through every batch, reaped %d", n)
+	}
+}
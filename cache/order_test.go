@@ -0,0 +1,46 @@
+package cache
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestInsertionOrder(t *testing.T) {
+	c := NewWithInsertionOrder(0)
+	defer c.Stop()
+
+	for _, k := range []string{"c", "a", "d", "b", "e"} {
+		c.Set(k, k, 0)
+	}
+	c.Set("a", "again", 0) // keeps its place
+	c.Delete("d")
+	c.Set("d", "d", 0) // moves to the end
+	c.Set("gone", 1, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	want := []string{"c", "a", "b", "e", "d"}
+	for i := 0; i < 3; i++ {
+		if got := c.Keys(); !reflect.DeepEqual(got, want) {
+			t.Fatalf("Keys: want %v, got %v", want, got)
+		}
+	}
+	var ranged []string
+	c.Range(func(key string, value interface{}) bool {
+		ranged = append(ranged, key)
+		return len(ranged) < 3
+	})
+	if !reflect.DeepEqual(ranged, want[:3]) {
+		t.Fatalf("Range: want %v, got %v", want[:3], ranged)
+	}
+
+	if got := c.Clone().Keys(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected Clone to keep insertion order, got %v", got)
+	}
+	c.CleanupNow()
+	c.Flush()
+	c.Set("z", 1, 0)
+	if got := c.Keys(); !reflect.DeepEqual(got, []string{"z"}) || c.order.Len() != 1 {
+		t.Fatalf("expected order reset by Flush, got %v (list %d)", got, c.order.Len())
+	}
+}
@@ -0,0 +1,107 @@
+package cache
+
+import (
+	"encoding/gob"
+	"io"
+	"time"
+)
+
+// persistedEntry is the on-disk form of an entry. TTL is the lifetime remaining
+// at save time; zero means the entry never expires.
+type persistedEntry struct {
+	Key   string
+	Value interface{}
+	TTL   time.Duration
+}
+
+// Save writes all live entries, with their remaining TTLs, to w using
+// encoding/gob. Expired and negative entries are skipped. Values are encoded as
+// interface{}, so every concrete value type other than gob's predeclared basic
+// types must be registered with gob.Register by the caller, both before Save
+// and before Load.
+//
+// The entries are copied under the read lock and encoded after it is released.
+func (c *Cache) Save(w io.Writer) error {
+	items := c.liveItems()
+	entries := make([]persistedEntry, len(items))
+	for i, it := range items {
+		entries[i] = persistedEntry{Key: it.Key, Value: it.Value, TTL: it.TTL}
+	}
+	return gob.NewEncoder(w).Encode(entries)
+}
+
+// liveItems copies every live, non-negative entry with its remaining TTL under
+// the read lock. Entries with no time left are left out.
+func (c *Cache) liveItems() []Item {
+	now := c.now()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	items := make([]Item, 0, len(c.data))
+	for k, e := range c.data {
+		if e.negative || e.expired(now) {
+			continue
+		}
+		var ttl time.Duration
+		if !e.expiresAt.IsZero() {
+			// An entry on its expiry instant has no lifetime left, and a zero
+			// TTL would bring it back as never expiring.
+			if ttl = e.expiresAt.Sub(now); ttl <= 0 {
+				continue
+			}
+		}
+		items = append(items, Item{Key: k, Value: e.value, TTL: ttl})
+	}
+	return items
+}
+
+// NewWithDrainOnStop creates a Cache that hands its live entries to drain when
+// it is stopped, so a shutting-down process can persist its warm cache for the
+// next instance; see WithDrainOnStop.
+func NewWithDrainOnStop(cleanerInterval time.Duration, drain func(items []Item)) *Cache {
+	return NewWithOptions(cleanerInterval, WithDrainOnStop(drain))
+}
+
+// WithDrainOnStop makes the first Stop call drain with every live entry and its
+// remaining TTL, in the form SetItems accepts, after the cleanup goroutine has
+// exited and queued AsyncSet writes have been applied, and before Stop returns.
+// drain runs without any cache lock held, so it may read the cache or call Save;
+// the entries stay in the cache. Expired and negative entries are left out.
+func WithDrainOnStop(drain func(items []Item)) Option {
+	return func(c *Cache) {
+		c.drain = drain
+	}
+}
+
+// Load reads entries written by Save and merges them into the cache, overwriting
+// existing keys. Each entry's deadline is recomputed as load time plus the TTL
+// it had left when saved. The input is decoded in full before the cache is
+// touched, so a decode error leaves the cache unchanged.
+func (c *Cache) Load(r io.Reader) error {
+	return c.loadFrom(r, false)
+}
+
+// LoadReplace is Load, but first discards every existing entry (firing OnEvict
+// with ReasonFlush), atomically with respect to other cache users.
+func (c *Cache) LoadReplace(r io.Reader) error {
+	return c.loadFrom(r, true)
+}
+
+func (c *Cache) loadFrom(r io.Reader, replace bool) error {
+	var entries []persistedEntry
+	if err := gob.NewDecoder(r).Decode(&entries); err != nil {
+		return err
+	}
+	now := c.now()
+	c.mu.Lock()
+	defer c.unlock()
+	if c.frozen.Load() {
+		return ErrFrozen
+	}
+	if replace {
+		c.flushLocked()
+	}
+	for _, pe := range entries {
+		c.setLocked(c.normalize(pe.Key), entry{value: pe.Value, expiresAt: c.expiry(now, pe.TTL), createdAt: now})
+	}
+	return nil
+}
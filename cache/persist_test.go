@@ -0,0 +1,136 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"testing"
+	"time"
+
+	"github.com/UtkrushtApps/go-concurrent-cache-answers/cache/cachetest"
+)
+
+type savedPoint struct{ X, Y int }
+
+func TestSaveLoadRoundTrip(t *testing.T) {
+	gob.Register(savedPoint{})
+
+	src := New(time.Hour)
+	defer src.Stop()
+	src.Set("str", "v", 0)
+	src.Set("point", savedPoint{1, 2}, time.Minute)
+	src.Set("gone", 1, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	var buf bytes.Buffer
+	if err := src.Save(&buf); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	dst := New(time.Hour)
+	defer dst.Stop()
+	dst.Set("existing", true, 0)
+	if err := dst.Load(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if v, ok := dst.Get("point"); !ok || v != (savedPoint{1, 2}) {
+		t.Fatalf("expected point to round-trip, got %v ok=%v", v, ok)
+	}
+	if _, ttl, _ := dst.GetWithTTL("point"); ttl <= 0 || ttl > time.Minute {
+		t.Fatalf("expected remaining TTL to be restored, got %v", ttl)
+	}
+	if _, ttl, ok := dst.GetWithTTL("str"); !ok || ttl != 0 {
+		t.Fatalf("expected non-expiring entry to round-trip, ttl=%v ok=%v", ttl, ok)
+	}
+	if _, ok := dst.Get("gone"); ok {
+		t.Fatalf("expired entries must not be saved")
+	}
+	if _, ok := dst.Get("existing"); !ok {
+		t.Fatalf("Load must merge into existing entries")
+	}
+
+	if err := dst.LoadReplace(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("LoadReplace: %v", err)
+	}
+	if _, ok := dst.Get("existing"); ok {
+		t.Fatalf("LoadReplace must discard existing entries")
+	}
+	if dst.Len() != 2 {
+		t.Fatalf("expected 2 entries after replace, got %d", dst.Len())
+	}
+}
+
+func TestSaveSkipsEntriesOnTheirExpiryInstant(t *testing.T) {
+	clock := cachetest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	c := NewWithClock(0, clock)
+	defer c.Stop()
+
+	c.Set("due", 1, time.Minute)
+	c.Set("live", 2, time.Hour)
+	clock.Advance(time.Minute)
+
+	var buf bytes.Buffer
+	if err := c.Save(&buf); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	restored := NewManual()
+	defer restored.Stop()
+	if err := restored.Load(&buf); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if _, ok := restored.Get("due"); ok {
+		t.Fatalf("expected an entry with no time left not to come back as never expiring")
+	}
+	if _, ok := restored.Get("live"); !ok {
+		t.Fatalf("expected live to be saved")
+	}
+}
+
+func TestLoadDecodeErrorLeavesCacheUnchanged(t *testing.T) {
+	c := New(time.Hour)
+	defer c.Stop()
+	c.Set("k", 1, 0)
+	if err := c.LoadReplace(bytes.NewReader([]byte("not gob"))); err == nil {
+		t.Fatalf("expected decode error")
+	}
+	if _, ok := c.Get("k"); !ok {
+		t.Fatalf("failed load must not flush the cache")
+	}
+}
+
+func TestDrainOnStop(t *testing.T) {
+	var drained []Item
+	var saved bytes.Buffer
+	var c *Cache
+	c = NewWithDrainOnStop(time.Millisecond, func(items []Item) {
+		drained = items
+		if err := c.Save(&saved); err != nil { // the cache is still usable
+			t.Errorf("Save during drain: %v", err)
+		}
+	})
+	c.Set("a", 1, 0)
+	c.Set("b", 2, time.Hour)
+	c.Set("gone", 3, time.Millisecond)
+	c.SetNegative("neg", 0)
+	c.AsyncSet("queued", 4, 0)
+	time.Sleep(5 * time.Millisecond)
+	c.Stop()
+	c.Stop()
+
+	if len(drained) != 3 {
+		t.Fatalf("expected the three live entries drained, got %+v", drained)
+	}
+	next := NewManual()
+	defer next.Stop()
+	next.SetItems(drained)
+	for k, want := range map[string]int{"a": 1, "b": 2, "queued": 4} {
+		if v, ok := next.Get(k); !ok || v != want {
+			t.Fatalf("%s: expected %d in the next instance, got %v ok=%v", k, want, v, ok)
+		}
+	}
+	if _, ttl, _ := next.GetWithTTL("b"); ttl <= 0 || ttl > time.Hour {
+		t.Fatalf("expected the remaining TTL carried over, got %v", ttl)
+	}
+	if saved.Len() == 0 {
+		t.Fatalf("expected Save to work from the drain")
+	}
+}
@@ -0,0 +1,139 @@
+package cache
+
+import (
+	"container/list"
+	"time"
+)
+
+// EvictionPolicy decides which entry a bounded cache evicts when it is full.
+// The cache reports every key it adds, reads, overwrites and removes, and asks
+// for a Victim when it must make room; it then removes that key, reporting it
+// through OnRemove like any other removal.
+//
+// All methods are called with the cache's write lock held, so a policy needs no
+// locking of its own, must be quick and must not call back into the cache. A
+// policy instance belongs to one cache.
+type EvictionPolicy interface {
+	// OnAdd is called when key is stored for the first time.
+	OnAdd(key string)
+	// OnAccess is called when key is read by a Get-style method or overwritten.
+	OnAccess(key string)
+	// OnRemove is called when key leaves the cache for any reason.
+	OnRemove(key string)
+	// Victim returns the key to evict next. It is only called while the cache
+	// holds entries; returning a key the cache does not hold stops the eviction.
+	Victim() string
+}
+
+// NewWithPolicy creates a Cache holding at most maxEntries entries that evicts
+// the entries policy picks, instead of using the built-in LRU list. Reads take
+// the write lock, as for NewWithCapacity. Weights set with SetWeighted are
+// recorded but not consulted, and LRUKeys and MRUKeys return nil.
+func NewWithPolicy(cleanerInterval time.Duration, maxEntries int, policy EvictionPolicy) *Cache {
+	return NewWithOptions(cleanerInterval, WithPolicy(policy), WithCapacity(maxEntries))
+}
+
+// NewLRUPolicy returns a policy evicting the least recently used key. It
+// behaves like the cache's built-in LRU and is mostly useful as a reference or
+// starting point for custom policies.
+func NewLRUPolicy() EvictionPolicy {
+	return &lruPolicy{order: list.New(), elems: make(map[string]*list.Element)}
+}
+
+type lruPolicy struct {
+	order *list.List // Front is most recently used
+	elems map[string]*list.Element
+}
+
+func (p *lruPolicy) OnAdd(key string) {
+	p.elems[key] = p.order.PushFront(key)
+}
+
+func (p *lruPolicy) OnAccess(key string) {
+	if el, ok := p.elems[key]; ok {
+		p.order.MoveToFront(el)
+	}
+}
+
+func (p *lruPolicy) OnRemove(key string) {
+	if el, ok := p.elems[key]; ok {
+		p.order.Remove(el)
+		delete(p.elems, key)
+	}
+}
+
+func (p *lruPolicy) Victim() string {
+	if back := p.order.Back(); back != nil {
+		return back.Value.(string)
+	}
+	return ""
+}
+
+// NewLFUPolicy returns a policy evicting the least frequently used key, counting
+// the add and every access, and the least recently used among keys with equal
+// counts. All operations are O(1).
+func NewLFUPolicy() EvictionPolicy {
+	return &lfuPolicy{items: make(map[string]*lfuItem), buckets: list.New()}
+}
+
+// lfuBucket holds the keys used freq times; front is most recently used.
+type lfuBucket struct {
+	freq int
+	keys *list.List
+}
+
+type lfuItem struct {
+	bucket *list.Element // Position in lfuPolicy.buckets
+	elem   *list.Element // Position in the bucket's keys
+}
+
+type lfuPolicy struct {
+	items   map[string]*lfuItem
+	buckets *list.List // Non-empty *lfuBucket values in increasing freq order
+}
+
+func (p *lfuPolicy) OnAdd(key string) {
+	front := p.buckets.Front()
+	if front == nil || front.Value.(*lfuBucket).freq != 1 {
+		front = p.buckets.PushFront(&lfuBucket{freq: 1, keys: list.New()})
+	}
+	p.items[key] = &lfuItem{bucket: front, elem: front.Value.(*lfuBucket).keys.PushFront(key)}
+}
+
+func (p *lfuPolicy) OnAccess(key string) {
+	it, ok := p.items[key]
+	if !ok {
+		return
+	}
+	cur := it.bucket
+	freq := cur.Value.(*lfuBucket).freq + 1
+	next := cur.Next()
+	if next == nil || next.Value.(*lfuBucket).freq != freq {
+		next = p.buckets.InsertAfter(&lfuBucket{freq: freq, keys: list.New()}, cur)
+	}
+	p.unlink(it)
+	it.bucket, it.elem = next, next.Value.(*lfuBucket).keys.PushFront(key)
+}
+
+func (p *lfuPolicy) OnRemove(key string) {
+	if it, ok := p.items[key]; ok {
+		p.unlink(it)
+		delete(p.items, key)
+	}
+}
+
+func (p *lfuPolicy) Victim() string {
+	if front := p.buckets.Front(); front != nil {
+		return front.Value.(*lfuBucket).keys.Back().Value.(string)
+	}
+	return ""
+}
+
+// unlink takes it out of its bucket, dropping the bucket once empty.
+func (p *lfuPolicy) unlink(it *lfuItem) {
+	b := it.bucket.Value.(*lfuBucket)
+	b.keys.Remove(it.elem)
+	if b.keys.Len() == 0 {
+		p.buckets.Remove(it.bucket)
+	}
+}
@@ -0,0 +1,116 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLFUPolicyEvictsLeastFrequentlyUsed(t *testing.T) {
+	c := NewWithPolicy(time.Hour, 3, NewLFUPolicy())
+	defer c.Stop()
+
+	c.Set("a", 1, 0)
+	c.Set("b", 2, 0)
+	c.Set("c", 3, 0)
+	c.Get("a")
+	c.Get("a")
+	c.Get("b")
+	c.Set("d", 4, 0) // c was used least
+	if c.Has("c") || !c.Has("a") || !c.Has("b") || !c.Has("d") {
+		t.Fatalf("expected c to be evicted, got %v", c.Keys())
+	}
+	c.Set("e", 5, 0) // d and the new arrivals tie at one use; d is older
+	if c.Has("d") {
+		t.Fatalf("expected ties to evict the least recently used, got %v", c.Keys())
+	}
+
+	c.Delete("e")
+	c.Delete("b")
+	c.Set("f", 6, 0)
+	c.Set("g", 7, 0)
+	c.Set("h", 8, 0) // f and g have one use each against a's three; f is older
+	if c.Len() != 3 || c.Has("f") || !c.Has("a") {
+		t.Fatalf("expected the policy to track removals, got %v", c.Keys())
+	}
+}
+
+func TestLFUPolicyVictimAfterRemovingLowestCount(t *testing.T) {
+	p := NewLFUPolicy()
+	for _, k := range []string{"a", "b", "c"} {
+		p.OnAdd(k)
+	}
+	p.OnAccess("b")
+	p.OnAccess("b")
+	p.OnAccess("c")
+	p.OnRemove("a") // empties the one-use bucket
+	if v := p.Victim(); v != "c" {
+		t.Fatalf("expected c, with the next lowest count, got %q", v)
+	}
+	p.OnRemove("c")
+	p.OnRemove("b")
+	if v := p.Victim(); v != "" {
+		t.Fatalf("expected no victim once empty, got %q", v)
+	}
+}
+
+func TestLRUPolicyMatchesBuiltin(t *testing.T) {
+	builtin := NewWithCapacity(time.Hour, 3)
+	defer builtin.Stop()
+	custom := NewWithPolicy(time.Hour, 3, NewLRUPolicy())
+	defer custom.Stop()
+
+	for _, c := range []*Cache{builtin, custom} {
+		c.Set("a", 1, 0)
+		c.Set("b", 2, 0)
+		c.Set("c", 3, 0)
+		c.Get("a")
+		c.Set("b", 20, 0)
+		c.Set("d", 4, 0)
+		c.Set("e", 5, 0)
+	}
+	for _, k := range []string{"a", "b", "c", "d", "e"} {
+		if builtin.Has(k) != custom.Has(k) {
+			t.Fatalf("policies disagree on %s: builtin %v, custom %v", k, builtin.Keys(), custom.Keys())
+		}
+	}
+}
+
+type recordingPolicy struct {
+	EvictionPolicy
+	calls []string
+}
+
+func (p *recordingPolicy) OnAdd(key string) {
+	p.calls = append(p.calls, "add "+key)
+	p.EvictionPolicy.OnAdd(key)
+}
+func (p *recordingPolicy) OnAccess(key string) {
+	p.calls = append(p.calls, "access "+key)
+	p.EvictionPolicy.OnAccess(key)
+}
+func (p *recordingPolicy) OnRemove(key string) {
+	p.calls = append(p.calls, "remove "+key)
+	p.EvictionPolicy.OnRemove(key)
+}
+
+func TestPolicyHooks(t *testing.T) {
+	p := &recordingPolicy{EvictionPolicy: NewLRUPolicy()}
+	c := NewWithPolicy(time.Hour, 1, p)
+	defer c.Stop()
+
+	c.Set("a", 1, 0)
+	c.Get("a")
+	c.Set("a", 2, 0)
+	c.Set("b", 3, 0)
+	c.Delete("b")
+
+	want := []string{"add a", "access a", "access a", "remove a", "add b", "remove b"}
+	if len(p.calls) != len(want) {
+		t.Fatalf("want %v, got %v", want, p.calls)
+	}
+	for i := range want {
+		if p.calls[i] != want[i] {
+			t.Fatalf("want %v, got %v", want, p.calls)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package cache
+
+import (
+	"math"
+	"time"
+)
+
+// Priority says how readily an entry may be evicted to respect a capacity or
+// size bound.
+type Priority int
+
+const (
+	// Normal entries are evicted in the usual order; see SetWeighted.
+	Normal Priority = iota
+	// Pinned entries are evicted only once no other entry remains.
+	Pinned
+)
+
+// pinnedWeight is the eviction weight of a Pinned entry. Victims come from the
+// lowest weight present, so the top weight is only reached when nothing else
+// is left. SetWeighted caps weights below it, so no other entry can share it.
+const pinnedWeight = math.MaxInt
+
+// SetWithPriority stores value like Set, with an eviction priority. A Pinned
+// entry, such as configuration or a feature flag, survives capacity and size
+// evictions for as long as the cache holds any other entry, however cold it
+// is; Pinned entries are evicted among themselves least-recently-used first.
+// Pinning does not affect expiry: the entry still expires after ttl, unless
+// ttl <= 0. The priority is replaced by the next write of the key and reported
+// by GetMeta.
+//
+// A pinned entry is stored with weight math.MaxInt, which SetWeighted does not
+// accept, so GetMeta reports Pinned only for entries stored here. Custom
+// eviction policies choose their own victims and ignore priorities.
+func (c *Cache) SetWithPriority(key string, value interface{}, ttl time.Duration, priority Priority) {
+	weight := 0
+	if priority == Pinned {
+		weight = pinnedWeight
+	}
+	c.setWeighted(key, value, ttl, weight)
+}
@@ -0,0 +1,66 @@
+package cache
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSetWithPrioritySparesPinnedEntries(t *testing.T) {
+	c := NewWithCapacity(time.Hour, 3)
+	defer c.Stop()
+
+	c.SetWithPriority("flags", 1, 0, Pinned)
+	c.Set("a", 2, 0)
+	c.SetWeighted("precious", 3, 0, 100)
+	c.Set("b", 4, 0) // evicts a
+	c.Set("c", 5, 0) // evicts b
+	if _, ok := c.Get("flags"); !ok {
+		t.Fatalf("expected the cold pinned entry to survive eviction")
+	}
+	if m, _ := c.GetMeta("flags"); m.Priority != Pinned {
+		t.Fatalf("expected GetMeta to report the pin, got %+v", m)
+	}
+
+	c.SetWithPriority("p1", 6, 0, Pinned) // evicts c
+	c.SetWithPriority("p2", 7, 0, Pinned) // evicts precious
+	if _, ok := c.Peek("precious"); ok {
+		t.Fatalf("expected weighted entries to be evicted before pinned ones")
+	}
+
+	// Only pinned entries left: they are evicted among themselves.
+	c.SetWithPriority("p3", 8, 0, Pinned)
+	if c.Len() != 3 {
+		t.Fatalf("expected the capacity to hold, got %d entries", c.Len())
+	}
+	if _, ok := c.Peek("flags"); ok {
+		t.Fatalf("expected the least recently used pinned entry to go first")
+	}
+}
+
+func TestPinnedEntriesStillExpire(t *testing.T) {
+	c := NewWithCapacity(time.Hour, 2)
+	defer c.Stop()
+
+	c.SetWithPriority("k", 1, time.Millisecond, Pinned)
+	time.Sleep(5 * time.Millisecond)
+	if _, ok := c.Get("k"); ok {
+		t.Fatalf("expected a pinned entry to honour its TTL")
+	}
+}
+
+func TestSetWeightedCannotPin(t *testing.T) {
+	c := NewWithCapacity(time.Hour, 2)
+	defer c.Stop()
+
+	c.SetWithPriority("pinned", 1, 0, Pinned)
+	c.SetWeighted("heavy", 2, 0, math.MaxInt)
+	if m, _ := c.GetMeta("heavy"); m.Priority != Normal || m.Weight != math.MaxInt-1 {
+		t.Fatalf("expected the top weight to be reserved for pins, got %+v", m)
+	}
+	c.Get("heavy")
+	c.Set("new", 3, 0)
+	if !c.Has("pinned") || c.Has("heavy") {
+		t.Fatalf("expected the heaviest unpinned entry to go before the pinned one, got %v", c.Keys())
+	}
+}
@@ -0,0 +1,134 @@
+package cache
+
+import "time"
+
+// refreshLead is the fraction of an entry's lifetime, taken from the end, at
+// which SetRefreshing reloads it: 10 means at 90% of the time from the write to
+// the stored deadline, whatever jitter or cap went into that deadline.
+const refreshLead = 10
+
+// refreshJob is one SetRefreshing registration. Entries point at the job that
+// stored them, so a background refresh can tell whether it still owns the key.
+type refreshJob struct {
+	ttl   time.Duration
+	fn    func() (interface{}, error)
+	timer *time.Timer // Fires when the next refresh is due; guarded by Cache.mu
+}
+
+// SetRefreshing stores the result of refresh under key with the given TTL and
+// keeps it fresh in the background: shortly before the entry expires, a
+// worker calls refresh again and updates the entry in place, so readers never
+// see a miss while refresh keeps succeeding. If refresh fails, the old value is
+// kept and the call is retried until the entry hard-expires, at which point the
+// key is gone and refreshing stops. Setting, deleting or otherwise replacing the
+// key also stops it. A ttl above the cache's maximum TTL (see NewWithMaxTTL) is
+// capped, and refreshes and retries follow the capped lifetime.
+//
+// The first call to refresh happens synchronously; if it fails, nothing is stored
+// and its error is returned. A ttl <= 0 stores that first value without expiry
+// and schedules no refresh. Later calls run on the cache's bounded pool of
+// refresh workers (see WithRefreshWorkers), not on a goroutine per key. Stop
+// cancels pending refreshes and waits for those already running to return.
+func (c *Cache) SetRefreshing(key string, ttl time.Duration, refresh func() (interface{}, error)) error {
+	v, err := refresh()
+	if err != nil {
+		return err
+	}
+	if ttl <= 0 {
+		c.Set(key, v, 0)
+		return nil
+	}
+	if c.maxTTL > 0 && ttl > c.maxTTL {
+		// Keep retries within the capped lifetime.
+		ttl = c.maxTTL
+	}
+	key = c.normalize(key)
+	job := &refreshJob{ttl: ttl, fn: refresh}
+	now := c.now()
+	c.mu.Lock()
+	defer c.unlock()
+	e := entry{value: v, expiresAt: c.expiry(now, ttl), refresh: job}
+	c.setLocked(key, e)
+	c.scheduleRefreshLocked(key, job, refreshWait(now, e.expiresAt))
+	return nil
+}
+
+// refreshWait returns how long after now, when an entry expiring at expiresAt
+// was stored, its refresh is due. The wait is measured from the stored deadline
+// rather than the requested TTL, so a jittered or capped entry is still
+// refreshed before it expires.
+func refreshWait(now, expiresAt time.Time) time.Duration {
+	life := expiresAt.Sub(now)
+	return life - life/refreshLead
+}
+
+// scheduleRefreshLocked arranges for job to refresh key on the worker pool
+// after wait, unless the cache is stopped or wait is negative, as it is for an
+// entry that never expires. c.mu must be held for writing.
+func (c *Cache) scheduleRefreshLocked(key string, job *refreshJob, wait time.Duration) {
+	if c.ctx.Err() != nil || wait < 0 {
+		return
+	}
+	job.timer = time.AfterFunc(wait, func() {
+		c.refreshPool.submit(func() { c.runRefresh(key, job) })
+	})
+}
+
+// runRefresh reloads key with job once and schedules the next refresh, unless
+// the cache was stopped, the entry expired or the key was written by anything
+// other than job. Failed reloads are retried sooner.
+func (c *Cache) runRefresh(key string, job *refreshJob) {
+	if !c.ownsRefresh(key, job, c.now()) {
+		return
+	}
+	v, err := job.fn()
+	retry := job.ttl / refreshLead / 2
+	if retry <= 0 {
+		retry = job.ttl
+	}
+	now := c.now()
+	c.mu.Lock()
+	defer c.unlock()
+	e, ok := c.data[key]
+	if !ok || e.refresh != job || e.expired(now) {
+		return
+	}
+	wait := retry
+	if err == nil {
+		e = entry{value: v, expiresAt: c.expiry(now, job.ttl), refresh: job}
+		c.setLocked(key, e)
+		wait = refreshWait(now, e.expiresAt)
+	}
+	c.scheduleRefreshLocked(key, job, wait)
+}
+
+// ownsRefresh reports whether key still holds a live entry stored by job, so
+// that a refresh whose key was deleted, flushed or replaced since its timer was
+// set does not call the user's function again.
+func (c *Cache) ownsRefresh(key string, job *refreshJob, now time.Time) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	e, ok := c.data[key]
+	return ok && e.refresh == job && !e.expired(now)
+}
+
+// cancel stops job's pending refresh timer, if it has one. A refresh already
+// handed to the worker pool still runs, but finds it no longer owns the key.
+// Cache.mu must be held for writing.
+func (job *refreshJob) cancel() {
+	if job != nil && job.timer != nil {
+		job.timer.Stop()
+	}
+}
+
+// stopRefreshes cancels the timers of every pending refresh and shuts the
+// worker pool down, waiting for running reloads. c.ctx must already be
+// cancelled, so that no new refresh is scheduled.
+func (c *Cache) stopRefreshes() {
+	c.mu.Lock()
+	for _, e := range c.data {
+		e.refresh.cancel()
+	}
+	c.mu.Unlock()
+	c.refreshPool.close()
+}
@@ -0,0 +1,160 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/UtkrushtApps/go-concurrent-cache-answers/cache/cachetest"
+)
+
+func TestSetRefreshingKeepsValueFresh(t *testing.T) {
+	// The fake clock stands still, so the entry cannot expire however late the
+	// real-time refresh timers fire; the test counts refreshes instead of racing
+	// the deadline.
+	clock := cachetest.NewFakeClock(time.Now())
+	c := NewWithClock(time.Hour, clock)
+
+	var calls int64
+	var kept atomic.Value
+	err := c.SetRefreshing("k", 40*time.Millisecond, func() (interface{}, error) {
+		n := atomic.AddInt64(&calls, 1)
+		if n == 3 {
+			v, _ := c.Get("k")
+			kept.Store(v)
+			return nil, errors.New("backend down")
+		}
+		return n, nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if v, ok := c.Get("k"); !ok {
+			t.Fatalf("expected a refreshing entry never to miss")
+		} else if v.(int64) >= 4 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected several refreshes past the failed one, got %d calls", atomic.LoadInt64(&calls))
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if v := kept.Load(); v != int64(2) {
+		t.Fatalf("expected the failed refresh to keep the old value, got %v", v)
+	}
+
+	c.Stop()
+	stopped := atomic.LoadInt64(&calls)
+	time.Sleep(100 * time.Millisecond)
+	if n := atomic.LoadInt64(&calls); n != stopped {
+		t.Fatalf("expected Stop to end refreshing, calls went from %d to %d", stopped, n)
+	}
+}
+
+func TestSetRefreshingStopsWhenReplacedOrFailing(t *testing.T) {
+	c := New(time.Hour)
+	defer c.Stop()
+
+	if err := c.SetRefreshing("k", time.Minute, func() (interface{}, error) {
+		return nil, errors.New("backend down")
+	}); err == nil {
+		t.Fatalf("expected the first refresh error to be returned")
+	}
+	if c.Has("k") {
+		t.Fatalf("expected nothing stored after a failed first refresh")
+	}
+
+	var calls int64
+	c.SetRefreshing("k", 20*time.Millisecond, func() (interface{}, error) {
+		return atomic.AddInt64(&calls, 1), nil
+	})
+	c.Set("k", "plain", 0)
+	time.Sleep(60 * time.Millisecond)
+	if v, _ := c.Get("k"); v != "plain" {
+		t.Fatalf("expected Set to take the key over, got %v", v)
+	}
+	if n := atomic.LoadInt64(&calls); n != 1 {
+		t.Fatalf("expected refreshing to stop after Set, got %d calls", n)
+	}
+
+	var failing int64
+	c.SetRefreshing("f", 20*time.Millisecond, func() (interface{}, error) {
+		if atomic.AddInt64(&failing, 1) == 1 {
+			return "first", nil
+		}
+		return nil, errors.New("backend down")
+	})
+	time.Sleep(60 * time.Millisecond)
+	if _, ok := c.Get("f"); ok {
+		t.Fatalf("expected the entry to hard-expire while refresh keeps failing")
+	}
+	n := atomic.LoadInt64(&failing)
+	time.Sleep(40 * time.Millisecond)
+	if atomic.LoadInt64(&failing) != n {
+		t.Fatalf("expected retries to stop after hard expiry")
+	}
+}
+
+func TestSetRefreshingStopsOnDeleteAndFlush(t *testing.T) {
+	c := New(time.Hour)
+	defer c.Stop()
+
+	var calls int64
+	refresh := func() (interface{}, error) { return atomic.AddInt64(&calls, 1), nil }
+	c.SetRefreshing("deleted", 20*time.Millisecond, refresh)
+	c.SetRefreshing("flushed", 20*time.Millisecond, refresh)
+	c.Delete("deleted")
+	c.Flush()
+	time.Sleep(60 * time.Millisecond)
+	if n := atomic.LoadInt64(&calls); n != 2 {
+		t.Fatalf("expected no refresh after Delete or Flush, got %d calls", n)
+	}
+}
+
+func TestSetRefreshingRefreshesWithinMaxTTL(t *testing.T) {
+	c := NewWithMaxTTL(time.Hour, 200*time.Millisecond)
+	defer c.Stop()
+
+	var calls int64
+	c.SetRefreshing("k", time.Minute, func() (interface{}, error) {
+		if atomic.AddInt64(&calls, 1) == 2 {
+			return nil, errors.New("backend down")
+		}
+		return "v", nil
+	})
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if _, ok := c.Get("k"); !ok {
+			t.Fatalf("expected the capped entry to be refreshed before it expires")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if n := atomic.LoadInt64(&calls); n < 4 {
+		t.Fatalf("expected refreshes at the capped TTL, got %d calls", n)
+	}
+}
+
+func TestSetRefreshingRefreshesJitteredEntriesBeforeExpiry(t *testing.T) {
+	c := NewWithTTLJitter(time.Hour, 0.5)
+	defer c.Stop()
+
+	keys := make([]string, 20)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("k%d", i)
+		c.SetRefreshing(keys[i], 400*time.Millisecond, func() (interface{}, error) { return i, nil })
+	}
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		for _, k := range keys {
+			if _, ok := c.Get(k); !ok {
+				t.Fatalf("expected %s to be refreshed before its jittered deadline", k)
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,81 @@
+package cache
+
+import "sort"
+
+// Resize changes the entry limit of a running cache. Shrinking evicts entries
+// straight away, in the order capacity eviction would pick them, until the cache
+// fits; evictions are reported to OnEvict with ReasonCapacity. A maxEntries <= 0
+// removes the limit. It is safe to call concurrently with other methods.
+//
+// On a frozen cache the new limit takes effect at once for later writes, but
+// the entries over it are only evicted by Unfreeze.
+//
+// Resizing a cache created without any bound starts recency tracking, seeded
+// from each entry's last access (or creation) time; removing the last bound stops
+// it again.
+func (c *Cache) Resize(maxEntries int) {
+	c.mu.Lock()
+	defer c.unlock()
+	if maxEntries <= 0 {
+		c.maxEntries = 0
+		if c.maxBytes <= 0 {
+			c.untrackLocked()
+		}
+		return
+	}
+	c.maxEntries = maxEntries
+	c.trackLocked()
+	if !c.frozen.Load() {
+		c.shrinkLocked()
+	}
+}
+
+// shrinkLocked evicts entries until the cache fits its entry limit. c.mu must be
+// held for writing.
+func (c *Cache) shrinkLocked() {
+	for c.maxEntries > 0 && len(c.data) > c.maxEntries && c.evictLocked("", false) {
+	}
+}
+
+// trackLocked starts recency tracking on a cache that had none, linking every
+// entry from least to most recently used. c.mu must be held for writing.
+func (c *Cache) trackLocked() {
+	if c.lru != nil || c.policy != nil {
+		return
+	}
+	c.ensureLRU()
+	keys := make([]string, 0, len(c.data))
+	for k := range c.data {
+		keys = append(keys, k)
+	}
+	lastUse := func(e entry) int64 {
+		if ns := e.accessed.Load(); ns != 0 {
+			return ns
+		}
+		return e.createdAt.UnixNano()
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return lastUse(c.data[keys[i]]) < lastUse(c.data[keys[j]])
+	})
+	for _, k := range keys {
+		e := c.data[k]
+		e.elem = c.lru.PushFront(k)
+		e.welem = c.linkWeightLocked(k, entry{}, e.weight)
+		c.data[k] = e
+	}
+}
+
+// untrackLocked stops recency tracking once no bound needs it. c.mu must be held
+// for writing.
+func (c *Cache) untrackLocked() {
+	if c.lru == nil {
+		return
+	}
+	for k, e := range c.data {
+		e.elem, e.welem = nil, nil
+		c.data[k] = e
+	}
+	c.lru = nil
+	c.weighted = nil
+	c.tracked.Store(false)
+}
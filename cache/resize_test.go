@@ -0,0 +1,90 @@
+package cache
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestResizeShrinksInLRUOrder(t *testing.T) {
+	c := NewWithCapacity(time.Hour, 5)
+	defer c.Stop()
+	log := newEvictLog(c)
+
+	for _, k := range []string{"a", "b", "c", "d", "e"} {
+		c.Set(k, 1, 0)
+	}
+	c.Get("a")
+	c.Resize(2)
+
+	if c.Len() != 2 || !c.Has("a") || !c.Has("e") {
+		t.Fatalf("expected the two most recent keys to remain, got %v", c.Keys())
+	}
+	if r, _ := log.reason("b"); r != ReasonCapacity {
+		t.Fatalf("expected shrink evictions to report ReasonCapacity, got %v", r)
+	}
+
+	c.Resize(0)
+	for i := 0; i < 10; i++ {
+		c.Set(strconv.Itoa(i), i, 0)
+	}
+	if c.Len() != 12 {
+		t.Fatalf("expected Resize(0) to remove the limit, got %d entries", c.Len())
+	}
+}
+
+func TestResizeStartsTrackingUnboundedCache(t *testing.T) {
+	c := New(time.Hour)
+	defer c.Stop()
+
+	c.Set("old", 1, 0)
+	time.Sleep(time.Millisecond)
+	c.Set("new", 2, 0)
+	time.Sleep(time.Millisecond)
+	c.Get("old")
+	c.Set("newest", 3, 0)
+
+	c.Resize(2)
+	if c.Has("new") || !c.Has("old") || !c.Has("newest") {
+		t.Fatalf("expected the least recently used key to go, got %v", c.Keys())
+	}
+	c.Set("more", 4, 0)
+	if c.Len() != 2 {
+		t.Fatalf("expected the new limit to hold, got %d entries", c.Len())
+	}
+}
+
+func TestResizeConcurrentWithReadsAndWrites(t *testing.T) {
+	c := New(time.Hour)
+	defer c.Stop()
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for n := 0; ; n++ {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				k := strconv.Itoa(n % 50)
+				c.Set(k, n, 0)
+				c.Get(k)
+				c.GetMulti([]string{k, "x"})
+			}
+		}(i)
+	}
+	for _, n := range []int{10, 0, 5, 20, 0, 3} {
+		c.Resize(n)
+		time.Sleep(2 * time.Millisecond)
+	}
+	close(stop)
+	wg.Wait()
+	if c.Len() > 3 {
+		t.Fatalf("expected the final limit to hold, got %d entries", c.Len())
+	}
+}
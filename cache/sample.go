@@ -0,0 +1,49 @@
+package cache
+
+import "time"
+
+// NewWithExpirySampling creates a Cache whose reads help with cleanup; see
+// WithExpirySampling.
+func NewWithExpirySampling(cleanerInterval time.Duration, every, size int) *Cache {
+	return NewWithOptions(cleanerInterval, WithExpirySampling(every, size))
+}
+
+// WithExpirySampling spreads cleanup across reads, as Redis does: every
+// every-th Get-style read also examines up to size entries picked at random
+// and reaps those that have expired, so that expired keys nobody reads do not
+// linger until the next sweep. Reaped entries count as expirations and are
+// reported to OnEvict as by any other expiry.
+//
+// The cost is bounded: a read that does sample examines at most size entries,
+// and it only samples if it can take the write lock without waiting, so reads
+// are never blocked by sampling. An every or size <= 0 disables sampling, the
+// default.
+func WithExpirySampling(every, size int) Option {
+	return func(c *Cache) {
+		if every <= 0 || size <= 0 {
+			c.sampleEvery, c.sampleSize = 0, 0
+			return
+		}
+		c.sampleEvery, c.sampleSize = uint64(every), size
+	}
+}
+
+// sampleExpired reaps the expired entries among up to sampleSize entries of
+// c.data, relying on map iteration starting at a random position. It gives up
+// rather than wait for the write lock. c.mu must not be held.
+func (c *Cache) sampleExpired(now time.Time) {
+	if !c.mu.TryLock() {
+		return
+	}
+	defer c.unlock()
+	n := 0
+	for k, e := range c.data {
+		if n == c.sampleSize {
+			break
+		}
+		n++
+		if e.expired(now) {
+			c.removeLocked(k, e, ReasonExpired)
+		}
+	}
+}
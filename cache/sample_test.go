@@ -0,0 +1,48 @@
+package cache
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestExpirySamplingReapsUnreadKeys(t *testing.T) {
+	c := NewWithExpirySampling(0, 1, 20)
+	defer c.Stop()
+
+	for i := 0; i < 50; i++ {
+		c.Set(strconv.Itoa(i), i, time.Millisecond)
+	}
+	c.Set("live", 1, 0)
+	time.Sleep(5 * time.Millisecond)
+
+	for i := 0; i < 100 && c.Stats().Expirations < 50; i++ {
+		if _, ok := c.Get("live"); !ok {
+			t.Fatalf("expected live to survive sampling")
+		}
+	}
+	if s := c.Stats(); s.Expirations != 50 {
+		t.Fatalf("expected reads to reap every expired key, got %+v", s)
+	}
+	c.mu.RLock()
+	left := len(c.data)
+	c.mu.RUnlock()
+	if left != 1 {
+		t.Fatalf("expected only live to remain in the map, got %d entries", left)
+	}
+}
+
+func TestExpirySamplingIsOffByDefault(t *testing.T) {
+	c := NewManual()
+	defer c.Stop()
+
+	c.Set("a", 1, time.Millisecond)
+	c.Set("live", 1, 0)
+	time.Sleep(5 * time.Millisecond)
+	for i := 0; i < 10; i++ {
+		c.Get("live")
+	}
+	if s := c.Stats(); s.Expirations != 0 {
+		t.Fatalf("expected no sampling without the option, got %+v", s)
+	}
+}
@@ -0,0 +1,82 @@
+package cache
+
+import "time"
+
+// ShardedCache spreads keys over independent Cache shards, each with its own
+// mutex, map and cleanup goroutine, so writes to keys in different shards do not
+// contend on a single lock. It offers the same core API as Cache.
+type ShardedCache struct {
+	shards []*Cache
+}
+
+// NewSharded creates a ShardedCache with the given number of shards (at least one).
+// Every shard runs its own cleanup goroutine with cleanerInterval, sweeping only
+// its own map under its own lock.
+func NewSharded(cleanerInterval time.Duration, shards int) *ShardedCache {
+	if shards < 1 {
+		shards = 1
+	}
+	s := &ShardedCache{shards: make([]*Cache, shards)}
+	for i := range s.shards {
+		s.shards[i] = New(cleanerInterval)
+	}
+	return s
+}
+
+// shard returns the shard owning key, chosen by an FNV-1a hash of the key.
+func (s *ShardedCache) shard(key string) *Cache {
+	const (
+		offset32 = 2166136261
+		prime32  = 16777619
+	)
+	h := uint32(offset32)
+	for i := 0; i < len(key); i++ {
+		h ^= uint32(key[i])
+		h *= prime32
+	}
+	return s.shards[h%uint32(len(s.shards))]
+}
+
+// Set inserts or updates a value in the cache with optional TTL.
+// If ttl <= 0, never expires.
+func (s *ShardedCache) Set(key string, value interface{}, ttl time.Duration) {
+	s.shard(key).Set(key, value, ttl)
+}
+
+// Get retrieves a value. Returns (value, true) if found and not expired, else (nil, false)
+func (s *ShardedCache) Get(key string) (interface{}, bool) {
+	return s.shard(key).Get(key)
+}
+
+// Delete removes a key from the cache
+func (s *ShardedCache) Delete(key string) {
+	s.shard(key).Delete(key)
+}
+
+// ShardStat describes one shard of a ShardedCache.
+type ShardStat struct {
+	Entries       int       // Live entries, as counted by the shard's Len
+	LastCleanupAt time.Time // When the shard's latest cleanup sweep finished; zero if none has run
+}
+
+// ShardStats returns one ShardStat per shard, in shard order. Entry counts far
+// above the mean point to a hot shard, for instance keys that differ only in a
+// way the hash does not spread well. A LastCleanupAt much older than the cleanup
+// interval means that shard's cleanup goroutine is not running. Shards are read
+// one at a time, so the result is not a consistent snapshot across shards.
+func (s *ShardedCache) ShardStats() []ShardStat {
+	stats := make([]ShardStat, len(s.shards))
+	for i, c := range s.shards {
+		stats[i] = ShardStat{Entries: c.Len(), LastCleanupAt: c.Stats().LastCleanupAt}
+	}
+	return stats
+}
+
+// Stop stops every shard's cleanup goroutine and waits for all of them to
+// finish, so no shard goroutine outlives it. Like Cache.Stop, it is safe to
+// call more than once.
+func (s *ShardedCache) Stop() {
+	for _, c := range s.shards {
+		c.Stop()
+	}
+}
@@ -0,0 +1,128 @@
+package cache
+
+import (
+	"runtime"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestShardedCacheBasicOperations(t *testing.T) {
+	s := NewSharded(10*time.Millisecond, 8)
+	defer s.Stop()
+
+	for i := 0; i < 100; i++ {
+		s.Set("k"+strconv.Itoa(i), i, 0)
+	}
+	for i := 0; i < 100; i++ {
+		if v, ok := s.Get("k" + strconv.Itoa(i)); !ok || v != i {
+			t.Fatalf("k%d: expected %d, got %v ok=%v", i, i, v, ok)
+		}
+	}
+	used := 0
+	for _, c := range s.shards {
+		if c.Len() > 0 {
+			used++
+		}
+	}
+	if used < 2 {
+		t.Fatalf("expected keys to spread over shards, only %d shard(s) used", used)
+	}
+
+	s.Delete("k1")
+	if _, ok := s.Get("k1"); ok {
+		t.Fatalf("expected k1 to be deleted")
+	}
+	s.Set("short", 1, 5*time.Millisecond)
+	time.Sleep(40 * time.Millisecond)
+	if _, ok := s.Get("short"); ok {
+		t.Fatalf("expected short-lived key to expire")
+	}
+}
+
+func benchmarkParallelSet(b *testing.B, set func(key string, value interface{}, ttl time.Duration)) {
+	keys := make([]string, 1024)
+	for i := range keys {
+		keys[i] = "key" + strconv.Itoa(i)
+	}
+	var n uint64
+	b.RunParallel(func(pb *testing.PB) {
+		i := atomic.AddUint64(&n, 1) * 7919
+		for pb.Next() {
+			set(keys[i%uint64(len(keys))], i, time.Minute)
+			i++
+		}
+	})
+}
+
+func BenchmarkSetSingleLock(b *testing.B) {
+	c := New(time.Minute)
+	defer c.Stop()
+	benchmarkParallelSet(b, c.Set)
+}
+
+func BenchmarkSetSharded(b *testing.B) {
+	s := NewSharded(time.Minute, 32)
+	defer s.Stop()
+	benchmarkParallelSet(b, s.Set)
+}
+
+func TestShardStats(t *testing.T) {
+	s := NewSharded(5*time.Millisecond, 4)
+	defer s.Stop()
+
+	for i := 0; i < 100; i++ {
+		s.Set("k"+strconv.Itoa(i), i, 0)
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		stats := s.ShardStats()
+		if len(stats) != 4 {
+			t.Fatalf("expected 4 shard stats, got %d", len(stats))
+		}
+		total, swept := 0, 0
+		for _, st := range stats {
+			total += st.Entries
+			if !st.LastCleanupAt.IsZero() {
+				swept++
+			}
+		}
+		if total != 100 {
+			t.Fatalf("expected shard entry counts to sum to 100, got %d", total)
+		}
+		if swept == len(stats) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected every shard to report a cleanup, only %d did", swept)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestShardedStopDrainsEveryShard(t *testing.T) {
+	startG := runtime.NumGoroutine()
+	s := NewSharded(time.Millisecond, 16)
+	if n := runtime.NumGoroutine(); n < startG+16 {
+		t.Fatalf("expected a cleanup goroutine per shard, have %d more goroutines", n-startG)
+	}
+	s.Set("x", 1, time.Millisecond)
+	s.Stop()
+	s.Stop()
+	waitForGoroutines(t, startG)
+}
+
+// waitForGoroutines fails t unless the number of goroutines drops back to at
+// most want within a second. Goroutines finish shortly after signalling their
+// WaitGroup, so right after Stop they may still be counted.
+func waitForGoroutines(t *testing.T, want int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > want {
+		if time.Now().After(deadline) {
+			t.Fatalf("goroutine leak: %d goroutines left, want at most %d", runtime.NumGoroutine(), want)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
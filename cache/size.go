@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrValueTooLarge is returned by TrySet, Increment and Decrement for a value
+// whose estimated size exceeds the cache's maximum value size; see
+// NewWithMaxValueSize.
+var ErrValueTooLarge = errors.New("cache: value exceeds the maximum value size")
+
+// defaultEntrySize is the cost charged per entry when no sizer is configured.
+const defaultEntrySize = 64
+
+// NewWithMaxBytes creates a Cache whose entries' estimated sizes sum to at most
+// maxBytes. sizer estimates the size of a value in bytes; if it is nil every
+// entry costs a fixed 64 bytes. When a Set would exceed the budget,
+// least-recently-used entries are evicted until it fits. A value that alone
+// exceeds maxBytes is still stored, after everything else has been evicted.
+// A maxBytes <= 0 disables the budget. sizer runs under the write lock and must
+// not call back into the cache.
+func NewWithMaxBytes(cleanerInterval time.Duration, maxBytes int64, sizer func(value interface{}) int64) *Cache {
+	return NewWithOptions(cleanerInterval, WithMaxBytes(maxBytes, sizer))
+}
+
+// NewWithMaxValueSize creates a Cache that refuses to store any value whose
+// estimated size exceeds maxValueBytes, so one giant blob cannot crowd out
+// everything else. sizer estimates sizes as in NewWithMaxBytes. An oversized
+// write is dropped by every method that stores values, leaving any previous
+// entry for the key untouched; TrySet reports it as ErrValueTooLarge, while Set
+// keeps its signature and drops it silently. A maxValueBytes <= 0 disables the
+// limit.
+func NewWithMaxValueSize(cleanerInterval time.Duration, maxValueBytes int64, sizer func(value interface{}) int64) *Cache {
+	return NewWithOptions(cleanerInterval, WithMaxValueSize(maxValueBytes, sizer))
+}
+
+// TrySet is Set that reports a rejected write: it returns ErrFrozen if the
+// cache is frozen and ErrValueTooLarge if value exceeds the maximum value size,
+// storing nothing in either case.
+func (c *Cache) TrySet(key string, value interface{}, ttl time.Duration) error {
+	key = c.normalize(key)
+	expiresAt := c.expiry(c.now(), ttl)
+	c.mu.Lock()
+	defer c.unlock()
+	if c.frozen.Load() {
+		return ErrFrozen
+	}
+	if !c.setLocked(key, entry{value: value, expiresAt: expiresAt}) {
+		return ErrValueTooLarge
+	}
+	return nil
+}
+
+// SizeBytes returns the estimated total size of all stored entries, including
+// expired entries not yet reaped. It does not take the cache lock.
+func (c *Cache) SizeBytes() int64 {
+	return c.bytes.Load()
+}
+
+// sizeOf estimates the size of value with the configured sizer.
+func (c *Cache) sizeOf(value interface{}) int64 {
+	if c.sizer == nil {
+		return defaultEntrySize
+	}
+	if n := c.sizer(value); n > 0 {
+		return n
+	}
+	return 0
+}
@@ -0,0 +1,99 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func byteLen(v interface{}) int64 { return int64(len(v.([]byte))) }
+
+func TestMaxBytesEvictsLRU(t *testing.T) {
+	c := NewWithMaxBytes(time.Hour, 100, byteLen)
+	defer c.Stop()
+
+	c.Set("a", make([]byte, 40), 0)
+	c.Set("b", make([]byte, 40), 0)
+	c.Get("a")
+	c.Set("c", make([]byte, 40), 0) // evicts b, the least recently used
+
+	if _, ok := c.Get("b"); ok {
+		t.Fatalf("expected b to be evicted")
+	}
+	if n := c.SizeBytes(); n != 80 {
+		t.Fatalf("expected 80 bytes, got %d", n)
+	}
+
+	c.Set("a", make([]byte, 10), 0) // shrinking an entry frees budget
+	c.Delete("c")
+	if n := c.SizeBytes(); n != 10 {
+		t.Fatalf("expected 10 bytes after shrink and delete, got %d", n)
+	}
+}
+
+func TestMaxBytesOversizedValue(t *testing.T) {
+	c := NewWithMaxBytes(time.Hour, 100, byteLen)
+	defer c.Stop()
+
+	c.Set("a", make([]byte, 50), 0)
+	c.Set("huge", make([]byte, 500), 0)
+	if _, ok := c.Get("huge"); !ok {
+		t.Fatalf("expected oversized value to be stored")
+	}
+	if c.Len() != 1 {
+		t.Fatalf("expected everything else to be evicted, got %d entries", c.Len())
+	}
+}
+
+func TestDefaultEntrySize(t *testing.T) {
+	c := NewWithMaxBytes(time.Hour, 3*defaultEntrySize, nil)
+	defer c.Stop()
+
+	for _, k := range []string{"a", "b", "c", "d"} {
+		c.Set(k, k, 0)
+	}
+	if c.Len() != 3 || c.SizeBytes() != 3*defaultEntrySize {
+		t.Fatalf("expected 3 fixed-size entries, got %d entries / %d bytes", c.Len(), c.SizeBytes())
+	}
+}
+
+func TestMaxValueSizeRejectsOversizedValues(t *testing.T) {
+	c := NewWithMaxValueSize(0, 8, func(v interface{}) int64 {
+		s, _ := v.(string) // nil for negative entries
+		return int64(len(s))
+	})
+	defer c.Stop()
+
+	if err := c.TrySet("k", "small", 0); err != nil {
+		t.Fatalf("expected a small value to be stored, got %v", err)
+	}
+	if err := c.TrySet("k", "far too large", 0); err != ErrValueTooLarge {
+		t.Fatalf("expected ErrValueTooLarge, got %v", err)
+	}
+	if v, _ := c.Get("k"); v != "small" {
+		t.Fatalf("expected the rejected write to leave the old value, got %v", v)
+	}
+	c.Set("big", "far too large", 0)
+	if _, ok := c.Get("big"); ok {
+		t.Fatalf("expected Set to drop an oversized value")
+	}
+	if c.SizeBytes() != 5 {
+		t.Fatalf("expected only the small value to be accounted, got %d", c.SizeBytes())
+	}
+	c.SetNegative("neg", 0)
+	if _, negative, ok := c.Lookup("neg"); !ok || !negative {
+		t.Fatalf("expected negative entries to be exempt from the limit")
+	}
+}
+
+func TestMaxValueSizeKeepsTheMaxBytesSizer(t *testing.T) {
+	c := NewWithOptions(0, WithMaxBytes(100, byteLen), WithMaxValueSize(8, nil))
+	defer c.Stop()
+
+	c.Set("k", make([]byte, 6), 0)
+	if c.SizeBytes() != 6 {
+		t.Fatalf("expected WithMaxBytes' sizer to stay in use, got %d bytes", c.SizeBytes())
+	}
+	if err := c.TrySet("big", make([]byte, 9), 0); err != ErrValueTooLarge {
+		t.Fatalf("expected the limit to be measured with the same sizer, got %v", err)
+	}
+}
@@ -0,0 +1,98 @@
+package cache
+
+import (
+	"math"
+	"time"
+)
+
+// SetWithStale stores a value that is fresh for the fresh duration and may then
+// be served as stale for a further stale duration, after which it expires like
+// any other entry. The cleanup goroutine only reaps it once fresh+stale has
+// passed. A fresh <= 0 stores the value as with Set and no stale window.
+func (c *Cache) SetWithStale(key string, value interface{}, fresh, stale time.Duration) {
+	if fresh <= 0 {
+		c.Set(key, value, 0)
+		return
+	}
+	key = c.normalize(key)
+	now := c.now()
+	c.mu.Lock()
+	defer c.unlock()
+	c.setLocked(key, c.staleEntry(now, value, fresh, stale))
+}
+
+// staleEntry builds the entry stored by SetWithStale.
+func (c *Cache) staleEntry(now time.Time, value interface{}, fresh, stale time.Duration) entry {
+	if stale < 0 {
+		stale = 0
+	}
+	total := fresh + stale
+	if total < fresh {
+		total = math.MaxInt64 // Saturate rather than wrap
+	}
+	return entry{
+		value:     value,
+		expiresAt: c.capDeadline(now, deadline(now, total)),
+		staleAt:   deadline(now, fresh),
+		freshFor:  fresh,
+		staleFor:  stale,
+	}
+}
+
+// GetStale is Get that also reports whether the value is past its fresh window.
+// Entries stored without a stale window are never reported as stale.
+func (c *Cache) GetStale(key string) (value interface{}, isStale bool, ok bool) {
+	key = c.normalize(key)
+	now := c.now()
+	e, ok := c.get(key, now)
+	if !ok || e.negative {
+		return nil, false, false
+	}
+	return e.value, e.stale(now), true
+}
+
+// OnStale registers fn to reload stale values in the background. When a Get,
+// GetStale or GetMulti serves a value from its stale window, the cache calls fn
+// for that key on its refresh worker pool (see WithRefreshWorkers) and stores the
+// result with the entry's original fresh and stale windows. Only one reload per
+// key runs at a time. If fn returns an error, or the key was written in the
+// meantime, the entry is left alone and the next stale read tries again. A later
+// call to OnStale replaces fn; passing nil disables background reloads.
+func (c *Cache) OnStale(fn func(key string) (interface{}, error)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.revalidator = fn
+}
+
+// revalidateLocked starts a background reload of the stale entry e unless one is
+// already running or no revalidator is registered. c.mu must be held for writing.
+func (c *Cache) revalidateLocked(key string, e entry) {
+	if c.revalidator == nil || e.refreshing {
+		return
+	}
+	fn, staleAt := c.revalidator, e.staleAt
+	if !c.refreshPool.submit(func() { c.revalidate(key, staleAt, fn) }) {
+		return
+	}
+	e.refreshing = true
+	c.data[key] = e
+}
+
+// revalidate reloads key with fn and stores the result if the entry still is the
+// stale one that triggered the reload, identified by its staleAt.
+func (c *Cache) revalidate(key string, staleAt time.Time, fn func(key string) (interface{}, error)) {
+	v, err := fn(key)
+	now := c.now()
+	c.mu.Lock()
+	defer c.unlock()
+	e, ok := c.data[key]
+	if !ok || !e.refreshing || !e.staleAt.Equal(staleAt) {
+		return
+	}
+	if err != nil {
+		e.refreshing = false
+		c.data[key] = e
+		return
+	}
+	c.setLocked(key, c.staleEntry(now, v, e.freshFor, e.staleFor))
+}
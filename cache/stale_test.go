@@ -0,0 +1,96 @@
+package cache
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetStaleWindows(t *testing.T) {
+	c := New(5 * time.Millisecond)
+	defer c.Stop()
+
+	c.SetWithStale("k", "v", 20*time.Millisecond, 40*time.Millisecond)
+	if v, stale, ok := c.GetStale("k"); !ok || stale || v != "v" {
+		t.Fatalf("expected fresh hit, got %v stale=%v ok=%v", v, stale, ok)
+	}
+	time.Sleep(35 * time.Millisecond)
+	if v, stale, ok := c.GetStale("k"); !ok || !stale || v != "v" {
+		t.Fatalf("expected stale hit, got %v stale=%v ok=%v", v, stale, ok)
+	}
+	c.mu.RLock()
+	_, present := c.data["k"]
+	c.mu.RUnlock()
+	if !present {
+		t.Fatalf("cleaner must not reap an entry inside its stale window")
+	}
+	time.Sleep(40 * time.Millisecond)
+	if _, _, ok := c.GetStale("k"); ok {
+		t.Fatalf("expected entry to expire after fresh+stale")
+	}
+}
+
+func TestOnStaleReloadsInBackground(t *testing.T) {
+	c := New(time.Hour)
+	defer c.Stop()
+
+	var calls int64
+	reloaded := make(chan struct{}, 10)
+	c.OnStale(func(key string) (interface{}, error) {
+		n := atomic.AddInt64(&calls, 1)
+		defer func() { reloaded <- struct{}{} }()
+		if n == 1 {
+			return nil, errors.New("backend down")
+		}
+		return "new", nil
+	})
+
+	c.SetWithStale("k", "old", 20*time.Millisecond, time.Minute)
+	time.Sleep(25 * time.Millisecond)
+
+	// First stale read serves the old value and triggers a reload, which fails.
+	if v, _ := c.Get("k"); v != "old" {
+		t.Fatalf("expected stale value to be served, got %v", v)
+	}
+	select {
+	case <-reloaded:
+	case <-time.After(time.Second):
+		t.Fatalf("expected the stale read to trigger a reload")
+	}
+	// The failure leaves the entry stale, so a later read retries. The loader
+	// signals before the failed reload clears its in-flight mark, so keep
+	// reading until the retry runs.
+	for deadline := time.Now().Add(time.Second); ; {
+		c.Get("k")
+		select {
+		case <-reloaded:
+		case <-time.After(time.Millisecond):
+			if time.Now().After(deadline) {
+				t.Fatalf("expected a read after the failed reload to retry it")
+			}
+			continue
+		}
+		break
+	}
+
+	// The reload signals before storing; wait for the store to land.
+	var v interface{}
+	var stale, ok bool
+	for i := 0; i < 100; i++ {
+		c.mu.RLock()
+		v = c.data["k"].value
+		c.mu.RUnlock()
+		if v == "new" {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	v, stale, ok = c.GetStale("k")
+	if !ok || stale || v != "new" {
+		t.Fatalf("expected fresh reloaded value, got %v stale=%v ok=%v", v, stale, ok)
+	}
+	if n := atomic.LoadInt64(&calls); n != 2 {
+		t.Fatalf("expected 2 reload attempts, got %d", n)
+	}
+}
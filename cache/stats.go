@@ -0,0 +1,65 @@
+package cache
+
+import "time"
+
+// CacheStats is a point-in-time snapshot of cache counters.
+type CacheStats struct {
+	Hits        uint64 // Get calls that found a live entry
+	Misses      uint64 // Get calls for absent or expired keys
+	Evictions   uint64 // Entries dropped to stay within a capacity or size bound
+	Expirations uint64 // Entries removed because their TTL passed
+
+	LastCleanupDuration time.Duration // Wall time of the latest cleanup sweep, including callbacks
+	LastCleanupScanned  int           // Expiry deadlines the latest sweep examined
+	LastCleanupRemoved  int           // Expired entries the latest sweep removed
+	LastCleanupAt       time.Time     // Wall time at which the latest sweep finished; zero if none has run
+
+	DroppedEvents uint64 // Change events not delivered because a subscriber's buffer was full
+
+	MaxEntries int   // The most entries held at once, counting expired ones not yet reaped
+	MaxBytes   int64 // The largest estimated size in bytes reached; see NewWithMaxBytes
+}
+
+// Stats returns the current counters. It reads atomics only and never takes c.mu,
+// so it is cheap to call from a metrics loop. Counters are read individually, so
+// the snapshot is not atomic across fields.
+func (c *Cache) Stats() CacheStats {
+	s := CacheStats{
+		Hits:        c.hits.Load(),
+		Misses:      c.misses.Load(),
+		Evictions:   c.evictions.Load(),
+		Expirations: c.expirations.Load(),
+
+		LastCleanupDuration: time.Duration(c.lastCleanup.Load()),
+		LastCleanupScanned:  int(c.lastScanned.Load()),
+		LastCleanupRemoved:  int(c.lastRemoved.Load()),
+
+		DroppedEvents: c.dropped.Load(),
+
+		MaxEntries: int(c.peakEntries.Load()),
+		MaxBytes:   c.peakBytes.Load(),
+	}
+	if ns := c.lastCleanupAt.Load(); ns != 0 {
+		s.LastCleanupAt = time.Unix(0, ns)
+	}
+	return s
+}
+
+// ResetStats zeroes all counters and the recorded cleanup figures, and restarts
+// the MaxEntries and MaxBytes high-water marks from the cache's current size.
+func (c *Cache) ResetStats() {
+	c.hits.Store(0)
+	c.misses.Store(0)
+	c.evictions.Store(0)
+	c.expirations.Store(0)
+	c.lastCleanup.Store(0)
+	c.lastScanned.Store(0)
+	c.lastRemoved.Store(0)
+	c.lastCleanupAt.Store(0)
+	c.dropped.Store(0)
+
+	c.mu.RLock()
+	c.peakEntries.Store(int64(len(c.data)))
+	c.peakBytes.Store(c.bytes.Load())
+	c.mu.RUnlock()
+}
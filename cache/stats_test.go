@@ -0,0 +1,109 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStatsCountsHitsMissesAndExpirations(t *testing.T) {
+	c := New(time.Hour)
+	defer c.Stop()
+
+	c.Set("live", 1, 0)
+	c.Set("short", 2, 5*time.Millisecond)
+	c.Get("live")
+	c.Get("absent")
+	time.Sleep(15 * time.Millisecond)
+	c.Get("short") // found but expired: a miss and an expiration
+
+	want := CacheStats{Hits: 1, Misses: 2, Expirations: 1, MaxEntries: 2, MaxBytes: 2 * defaultEntrySize}
+	if got := c.Stats(); got != want {
+		t.Fatalf("want %+v, got %+v", want, got)
+	}
+
+	c.ResetStats()
+	// Only "live" remains, so the high-water marks restart from one entry.
+	if got := c.Stats(); got != (CacheStats{MaxEntries: 1, MaxBytes: defaultEntrySize}) {
+		t.Fatalf("expected zeroed counters after reset, got %+v", got)
+	}
+}
+
+func TestStatsCountsEvictionsAndCleanup(t *testing.T) {
+	c := NewWithCapacity(10*time.Millisecond, 1)
+	defer c.Stop()
+
+	c.Set("a", 1, 0)
+	c.Set("b", 2, 5*time.Millisecond) // evicts a
+	time.Sleep(40 * time.Millisecond) // cleaner reaps b
+
+	s := c.Stats()
+	if s.Evictions != 1 || s.Expirations != 1 {
+		t.Fatalf("want 1 eviction and 1 expiration, got %+v", s)
+	}
+}
+
+func TestDeleteExpiredCountsExpirations(t *testing.T) {
+	c := NewManual()
+	defer c.Stop()
+
+	for _, k := range []string{"a", "b", "c"} {
+		c.Set(k, 1, time.Millisecond)
+	}
+	c.Set("live", 1, 0)
+	time.Sleep(5 * time.Millisecond)
+
+	c.Get("a") // reaped lazily
+	if n := c.DeleteExpired(); n != 2 {
+		t.Fatalf("expected DeleteExpired to remove 2 entries, removed %d", n)
+	}
+	if s := c.Stats(); s.Expirations != 3 {
+		t.Fatalf("expected lazy and swept expirations to be counted, got %+v", s)
+	}
+	if n := c.DeleteExpired(); n != 0 {
+		t.Fatalf("expected nothing left to remove, removed %d", n)
+	}
+}
+
+func TestStatsRecordLastCleanup(t *testing.T) {
+	c := NewManual()
+	defer c.Stop()
+
+	for _, k := range []string{"a", "b", "c"} {
+		c.Set(k, 1, time.Millisecond)
+	}
+	c.Delete("c") // leaves a stale deadline behind
+	c.Set("live", 1, time.Hour)
+	time.Sleep(5 * time.Millisecond)
+	c.DeleteExpired()
+
+	s := c.Stats()
+	if s.LastCleanupScanned != 3 || s.LastCleanupRemoved != 2 {
+		t.Fatalf("expected 3 scanned and 2 removed, got %+v", s)
+	}
+	if s.LastCleanupDuration <= 0 {
+		t.Fatalf("expected a sweep duration, got %v", s.LastCleanupDuration)
+	}
+}
+
+func TestStatsHighWaterMarks(t *testing.T) {
+	c := NewWithMaxBytes(0, 0, func(v interface{}) int64 { return int64(len(v.(string))) })
+	defer c.Stop()
+
+	c.Set("a", "xxxx", 0)
+	c.Set("b", "xxxxxx", 0)
+	c.Set("c", "x", 0)
+	c.Delete("b")
+	c.Delete("c")
+	if s := c.Stats(); s.MaxEntries != 3 || s.MaxBytes != 11 {
+		t.Fatalf("expected peaks of 3 entries and 11 bytes, got %+v", s)
+	}
+
+	c.ResetStats()
+	if s := c.Stats(); s.MaxEntries != 1 || s.MaxBytes != 4 {
+		t.Fatalf("expected ResetStats to restart peaks from the current size, got %+v", s)
+	}
+	c.Set("a", "xx", 0)
+	if s := c.Stats(); s.MaxEntries != 1 || s.MaxBytes != 4 {
+		t.Fatalf("expected a shrinking write to leave the peaks alone, got %+v", s)
+	}
+}
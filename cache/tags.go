@@ -0,0 +1,58 @@
+package cache
+
+import "time"
+
+// SetWithTags is Set that also attaches tags to the entry, so it can later be
+// removed together with every other entry sharing a tag via InvalidateTag.
+// Overwriting the key, with or without tags, replaces its previous tags.
+func (c *Cache) SetWithTags(key string, value interface{}, ttl time.Duration, tags ...string) {
+	key = c.normalize(key)
+	e := entry{value: value, expiresAt: c.expiry(c.now(), ttl)}
+	if len(tags) > 0 {
+		e.tags = append([]string(nil), tags...)
+	}
+	c.mu.Lock()
+	defer c.unlock()
+	c.setLocked(key, e)
+}
+
+// InvalidateTag deletes every entry carrying tag, under a single write lock, and
+// returns how many live entries were removed. OnEvict fires with ReasonDeleted
+// for each; tagged entries that had already expired are reaped as expired and
+// not counted.
+func (c *Cache) InvalidateTag(tag string) int {
+	now := c.now()
+	c.mu.Lock()
+	defer c.unlock()
+	n := 0
+	for key := range c.tags[tag] {
+		if c.deleteLocked(key, c.data[key], now) {
+			n++
+		}
+	}
+	return n
+}
+
+// tagLocked adds key to the index of each tag. c.mu must be held for writing.
+func (c *Cache) tagLocked(key string, tags []string) {
+	for _, t := range tags {
+		keys := c.tags[t]
+		if keys == nil {
+			keys = make(map[string]struct{})
+			c.tags[t] = keys
+		}
+		keys[key] = struct{}{}
+	}
+}
+
+// untagLocked removes key from the index of each tag, dropping tags that no
+// longer have any keys. c.mu must be held for writing.
+func (c *Cache) untagLocked(key string, tags []string) {
+	for _, t := range tags {
+		keys := c.tags[t]
+		delete(keys, key)
+		if len(keys) == 0 {
+			delete(c.tags, t)
+		}
+	}
+}
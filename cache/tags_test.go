@@ -0,0 +1,50 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInvalidateTag(t *testing.T) {
+	c := New(time.Hour)
+	defer c.Stop()
+
+	c.SetWithTags("user:1:profile", "p", 0, "user:1")
+	c.SetWithTags("user:1:prefs", "q", 0, "user:1", "prefs")
+	c.SetWithTags("user:2:prefs", "r", 0, "user:2", "prefs")
+
+	if n := c.InvalidateTag("user:1"); n != 2 {
+		t.Fatalf("expected 2 entries removed, got %d", n)
+	}
+	if _, ok := c.Get("user:1:profile"); ok {
+		t.Fatalf("tagged entry should be gone")
+	}
+	if _, ok := c.Get("user:2:prefs"); !ok {
+		t.Fatalf("untagged entry should survive")
+	}
+	// user:1:prefs was removed along with its "prefs" membership.
+	if keys := c.tags["prefs"]; len(keys) != 1 {
+		t.Fatalf("expected prefs to index only user:2:prefs, got %v", keys)
+	}
+	if n := c.InvalidateTag("user:1"); n != 0 {
+		t.Fatalf("expected nothing left to invalidate, got %d", n)
+	}
+}
+
+func TestTagIndexDoesNotLeak(t *testing.T) {
+	c := New(5 * time.Millisecond)
+	defer c.Stop()
+
+	c.SetWithTags("a", 1, 0, "t1")
+	c.Delete("a")
+	c.SetWithTags("b", 2, time.Millisecond, "t2")
+	c.SetWithTags("c", 3, 0, "t3")
+	c.Set("c", 3, 0) // overwrite without tags drops the old ones
+	time.Sleep(30 * time.Millisecond)
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if len(c.tags) != 0 {
+		t.Fatalf("expected empty tag index, got %v", c.tags)
+	}
+}
@@ -0,0 +1,145 @@
+package cache
+
+import (
+	"log"
+	"time"
+)
+
+// Backend is a secondary store behind a Tiered cache, typically larger and
+// slower than the primary. A ttl <= 0 means the value never expires, both when
+// the Tiered cache stores a value and when Get reports the remaining lifetime.
+type Backend interface {
+	Get(key string) (value interface{}, ttl time.Duration, ok bool, err error)
+	Set(key string, value interface{}, ttl time.Duration) error
+	Delete(key string) error
+}
+
+// NewCacheBackend adapts a Cache for use as the Backend of a Tiered cache. Its
+// methods never fail.
+func NewCacheBackend(c *Cache) Backend {
+	return cacheBackend{c}
+}
+
+type cacheBackend struct{ c *Cache }
+
+func (b cacheBackend) Get(key string) (interface{}, time.Duration, bool, error) {
+	v, ttl, ok := b.c.GetWithTTL(key)
+	return v, ttl, ok, nil
+}
+
+func (b cacheBackend) Set(key string, value interface{}, ttl time.Duration) error {
+	b.c.Set(key, value, ttl)
+	return nil
+}
+
+func (b cacheBackend) Delete(key string) error {
+	b.c.Delete(key)
+	return nil
+}
+
+// Tiered is a two-level cache: a bounded primary Cache in front of a Backend
+// that catches what the primary evicts. Each key normally lives in one tier:
+//
+//   - Demotion: when the primary evicts an entry for capacity, it is written to
+//     the backend with its remaining TTL. Expired, deleted and flushed entries
+//     are not demoted, nor are negative entries (see SetNegative), which a
+//     Backend has no way to store.
+//   - Promotion: when Get misses the primary but finds the key in the backend,
+//     the value is stored in the primary with the TTL the backend reported and
+//     then deleted from the backend. If the key was written to the primary in
+//     the meantime, the newer value wins: Get returns what the primary holds
+//     and leaves the backend copy alone, as it does when the primary refuses
+//     the write (see Freeze).
+//
+// Set writes to the primary and deletes any backend copy; Delete removes the key
+// from both. Backend errors are logged to ErrorLog and otherwise ignored: a
+// failed demotion loses the entry, a failed lookup is a miss, and a failed
+// delete may leave a stale copy in the backend.
+type Tiered struct {
+	primary *Cache
+	backend Backend
+
+	// ErrorLog receives backend errors; nil means the log package's standard
+	// logger. Set it before the Tiered cache is in use.
+	ErrorLog *log.Logger
+}
+
+// NewTiered layers primary over backend. It registers an eviction callback on
+// primary, so primary should not back more than one Tiered cache; its capacity
+// or size bound decides when entries spill over. The caller still owns primary
+// and must Stop it.
+func NewTiered(primary *Cache, backend Backend) *Tiered {
+	t := &Tiered{primary: primary, backend: backend}
+	primary.onEvicted(t.demote)
+	return t
+}
+
+// Get returns the value for key from the primary, or from the backend,
+// promoting it into the primary.
+func (t *Tiered) Get(key string) (interface{}, bool) {
+	if v, ok := t.primary.Get(key); ok {
+		return v, true
+	}
+	v, ttl, ok, err := t.backend.Get(key)
+	if err != nil {
+		t.logf("cache: tiered get %q: %v", key, err)
+		return nil, false
+	}
+	if !ok {
+		return nil, false
+	}
+	if !t.primary.SetIfAbsent(key, v, ttl) {
+		// Either a concurrent write beat the promotion, and its value is newer
+		// than the backend copy, or the primary refused the write. The backend
+		// copy stays either way.
+		if pv, negative, ok := t.primary.Lookup(key); ok {
+			return pv, !negative
+		}
+		return v, true
+	}
+	if err := t.backend.Delete(key); err != nil {
+		t.logf("cache: tiered promote %q: %v", key, err)
+	}
+	return v, true
+}
+
+// Set stores value in the primary with the given TTL and drops any copy of key
+// from the backend.
+func (t *Tiered) Set(key string, value interface{}, ttl time.Duration) {
+	t.primary.Set(key, value, ttl)
+	if err := t.backend.Delete(key); err != nil {
+		t.logf("cache: tiered set %q: %v", key, err)
+	}
+}
+
+// Delete removes key from both tiers.
+func (t *Tiered) Delete(key string) {
+	t.primary.Delete(key)
+	if err := t.backend.Delete(key); err != nil {
+		t.logf("cache: tiered delete %q: %v", key, err)
+	}
+}
+
+// demote writes an entry evicted from the primary for capacity to the backend.
+func (t *Tiered) demote(ev evicted) {
+	if ev.reason != ReasonCapacity || ev.negative {
+		return
+	}
+	var ttl time.Duration
+	if !ev.expiresAt.IsZero() {
+		if ttl = ev.expiresAt.Sub(t.primary.now()); ttl <= 0 {
+			return
+		}
+	}
+	if err := t.backend.Set(ev.key, ev.value, ttl); err != nil {
+		t.logf("cache: tiered demote %q: %v", ev.key, err)
+	}
+}
+
+func (t *Tiered) logf(format string, args ...interface{}) {
+	if t.ErrorLog != nil {
+		t.ErrorLog.Printf(format, args...)
+		return
+	}
+	log.Printf(format, args...)
+}
@@ -0,0 +1,124 @@
+package cache
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTieredDemotesAndPromotes(t *testing.T) {
+	primary := NewWithCapacity(time.Hour, 2)
+	defer primary.Stop()
+	secondary := New(time.Hour)
+	defer secondary.Stop()
+	tc := NewTiered(primary, NewCacheBackend(secondary))
+
+	tc.Set("a", 1, time.Minute)
+	tc.Set("b", 2, 0)
+	tc.Set("c", 3, 0) // evicts a into the backend
+
+	if primary.Has("a") {
+		t.Fatalf("expected a to leave the primary")
+	}
+	if _, ttl, ok := secondary.GetWithTTL("a"); !ok || ttl <= 0 || ttl > time.Minute {
+		t.Fatalf("expected a to be demoted with its remaining TTL, got %v ok=%v", ttl, ok)
+	}
+
+	if v, ok := tc.Get("a"); !ok || v != 1 {
+		t.Fatalf("expected a from the backend, got %v ok=%v", v, ok)
+	}
+	if !primary.Has("a") || secondary.Has("a") {
+		t.Fatalf("expected a to be promoted out of the backend")
+	}
+	if !secondary.Has("b") {
+		t.Fatalf("expected the promotion to demote b in turn")
+	}
+
+	tc.Delete("b")
+	if _, ok := tc.Get("b"); ok {
+		t.Fatalf("expected Delete to remove b from both tiers")
+	}
+	primary.Delete("c")
+	if secondary.Has("c") {
+		t.Fatalf("expected plain deletes not to demote")
+	}
+}
+
+func TestTieredDoesNotDemoteNegativeEntries(t *testing.T) {
+	primary := NewWithCapacity(time.Hour, 1)
+	defer primary.Stop()
+	secondary := New(time.Hour)
+	defer secondary.Stop()
+	tc := NewTiered(primary, NewCacheBackend(secondary))
+
+	primary.SetNegative("missing", time.Minute)
+	tc.Set("a", 1, 0) // evicts the negative entry
+
+	if secondary.Has("missing") {
+		t.Fatalf("expected the negative entry not to be demoted")
+	}
+	if v, ok := tc.Get("missing"); ok {
+		t.Fatalf("expected a miss for the negative entry, got %v", v)
+	}
+}
+
+// racingBackend runs beforeGet ahead of each lookup, standing in for a write
+// that lands while Tiered.Get is reading the backend.
+type racingBackend struct {
+	Backend
+	beforeGet func()
+}
+
+func (b racingBackend) Get(key string) (interface{}, time.Duration, bool, error) {
+	b.beforeGet()
+	return b.Backend.Get(key)
+}
+
+func TestTieredPromotionYieldsToConcurrentSet(t *testing.T) {
+	primary := New(time.Hour)
+	defer primary.Stop()
+	secondary := New(time.Hour)
+	defer secondary.Stop()
+	secondary.Set("k", "old", 0)
+	tc := NewTiered(primary, racingBackend{NewCacheBackend(secondary), func() { primary.Set("k", "new", 0) }})
+
+	if v, ok := tc.Get("k"); !ok || v != "new" {
+		t.Fatalf("expected the concurrent write to win, got %v ok=%v", v, ok)
+	}
+	if v, _ := primary.Get("k"); v != "new" {
+		t.Fatalf("expected the promotion not to overwrite the newer value, got %v", v)
+	}
+}
+
+type failingBackend struct{}
+
+var errBackendDown = errors.New("backend down")
+
+func (failingBackend) Get(string) (interface{}, time.Duration, bool, error) {
+	return nil, 0, false, errBackendDown
+}
+func (failingBackend) Set(string, interface{}, time.Duration) error { return errBackendDown }
+func (failingBackend) Delete(string) error                          { return errBackendDown }
+
+func TestTieredBackendErrorsAreLogged(t *testing.T) {
+	primary := NewWithCapacity(time.Hour, 1)
+	defer primary.Stop()
+	tc := NewTiered(primary, failingBackend{})
+	var buf bytes.Buffer
+	tc.ErrorLog = log.New(&buf, "", 0)
+
+	tc.Set("a", 1, 0)
+	tc.Set("b", 2, 0) // demotion of a fails
+	if v, ok := tc.Get("b"); !ok || v != 2 {
+		t.Fatalf("expected primary hits despite backend errors, got %v ok=%v", v, ok)
+	}
+	if _, ok := tc.Get("a"); ok {
+		t.Fatalf("expected a failed backend lookup to miss")
+	}
+	if out := buf.String(); !strings.Contains(out, `demote "a"`) || !strings.Contains(out, errBackendDown.Error()) {
+		t.Fatalf("expected backend errors to be logged, got %q", out)
+	}
+}
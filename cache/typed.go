@@ -0,0 +1,109 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// typedEntry is the generic counterpart of entry.
+type typedEntry[V any] struct {
+	value     V
+	expiresAt time.Time // If zero value, never expires
+}
+
+// TypedCache is a type-safe variant of Cache for callers that know their key and
+// value types up front. It avoids type assertions on Get and boxing of values.
+// It shares Cache's locking and cleanup design; the name differs only because
+// Go does not allow a generic and a non-generic type to share a name.
+type TypedCache[K comparable, V any] struct {
+	mu              sync.RWMutex
+	data            map[K]typedEntry[V]
+	wg              sync.WaitGroup
+	cancel          context.CancelFunc
+	ctx             context.Context
+	cleanerInterval time.Duration
+	stopOnce        sync.Once
+}
+
+// NewTyped creates a new TypedCache and starts its background cleanup goroutine
+// with the given cleanup interval. A cleanerInterval <= 0 starts no goroutine.
+func NewTyped[K comparable, V any](cleanerInterval time.Duration) *TypedCache[K, V] {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &TypedCache[K, V]{
+		data:            make(map[K]typedEntry[V]),
+		ctx:             ctx,
+		cancel:          cancel,
+		cleanerInterval: cleanerInterval,
+	}
+	if cleanerInterval > 0 {
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			runCleanup(c.ctx, func() time.Duration { return c.cleanerInterval }, c.sweep)
+		}()
+	}
+	return c
+}
+
+// Set inserts or updates a value in the cache with optional TTL.
+// If ttl <= 0, never expires.
+func (c *TypedCache[K, V]) Set(key K, value V, ttl time.Duration) {
+	expiresAt := deadline(time.Now(), ttl)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.data[key] = typedEntry[V]{value: value, expiresAt: expiresAt}
+}
+
+// Get retrieves a value. Returns (value, true) if found and not expired, else
+// the zero value of V and false.
+func (c *TypedCache[K, V]) Get(key K) (V, bool) {
+	c.mu.RLock()
+	e, ok := c.data[key]
+	c.mu.RUnlock()
+
+	var zero V
+	if !ok {
+		return zero, false
+	}
+	if !e.expiresAt.IsZero() && time.Now().After(e.expiresAt) {
+		c.mu.Lock()
+		if e2, stillOk := c.data[key]; stillOk && e2.expiresAt.Equal(e.expiresAt) {
+			delete(c.data, key)
+		}
+		c.mu.Unlock()
+		return zero, false
+	}
+	return e.value, true
+}
+
+// Delete removes a key from the cache
+func (c *TypedCache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.data, key)
+}
+
+// Stop stops the background cleanup goroutine and waits for completion. As with
+// Cache.Stop, only the first call does anything and the cache stays usable.
+func (c *TypedCache[K, V]) Stop() {
+	c.stopOnce.Do(func() {
+		c.cancel()
+		c.wg.Wait()
+	})
+}
+
+// sweep deletes every expired entry in one pass under the write lock and
+// returns the number removed.
+func (c *TypedCache[K, V]) sweep(now time.Time) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	removed := 0
+	for k, e := range c.data {
+		if !e.expiresAt.IsZero() && now.After(e.expiresAt) {
+			delete(c.data, k)
+			removed++
+		}
+	}
+	return removed
+}
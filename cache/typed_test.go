@@ -0,0 +1,44 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTypedCacheBasicOperations(t *testing.T) {
+	c := NewTyped[string, int](20 * time.Millisecond)
+	defer c.Stop()
+
+	c.Set("answer", 42, time.Second)
+	v, ok := c.Get("answer")
+	if !ok || v != 42 {
+		t.Fatalf("expected 42, got %v ok=%v", v, ok)
+	}
+
+	c.Delete("answer")
+	if v, ok := c.Get("answer"); ok || v != 0 {
+		t.Fatalf("expected zero value miss after delete, got %v ok=%v", v, ok)
+	}
+}
+
+func TestTypedCacheExpiry(t *testing.T) {
+	c := NewTyped[int, []byte](10 * time.Millisecond)
+	defer c.Stop()
+
+	c.Set(1, []byte("x"), 5*time.Millisecond)
+	c.Set(2, []byte("y"), 0)
+	time.Sleep(40 * time.Millisecond)
+
+	c.mu.RLock()
+	_, reaped := c.data[1]
+	c.mu.RUnlock()
+	if reaped {
+		t.Fatalf("expected cleaner to remove expired key")
+	}
+	if v, ok := c.Get(1); ok || v != nil {
+		t.Fatalf("expected nil miss for expired key, got %v ok=%v", v, ok)
+	}
+	if _, ok := c.Get(2); !ok {
+		t.Fatalf("expected non-TTL key to persist")
+	}
+}
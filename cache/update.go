@@ -0,0 +1,90 @@
+package cache
+
+import "time"
+
+// Update atomically replaces the value of key with the result of fn. fn is
+// called under the write lock with the current value and whether key had a live
+// entry; missing, expired and negative entries are reported as not found with a
+// nil old value. If fn returns keep, its value is stored and returned; otherwise
+// the entry is deleted (with ReasonDeleted, if there was one) and Update returns
+// nil.
+//
+// An existing entry keeps its TTL, tags and weight; its CreatedAt and
+// LastAccessedAt start over, as for any write that stores a new value. A key
+// that was not found is created as Set with a ttl <= 0 would create it: without
+// expiry, or with the maximum TTL of a NewWithMaxTTL cache; call Touch
+// afterwards to give it a TTL. Update also returns nil if the write is refused,
+// because the cache is frozen or the value exceeds the maximum value size.
+// Because fn runs with the lock held it must be quick and must not call any
+// method on the same cache, which would deadlock.
+func (c *Cache) Update(key string, fn func(old interface{}, found bool) (new interface{}, keep bool)) interface{} {
+	key = c.normalize(key)
+	now := c.now()
+	c.mu.Lock()
+	defer c.unlock()
+	if c.frozen.Load() {
+		return nil
+	}
+	e, ok := c.data[key]
+	if ok && e.expired(now) {
+		c.removeLocked(key, e, ReasonExpired)
+		ok = false
+	}
+	found := ok && !e.negative
+	var old interface{}
+	if found {
+		old = c.copyOut(e.value)
+	}
+	v, keep := fn(old, found)
+	if !keep {
+		if ok {
+			c.removeLocked(key, e, ReasonDeleted)
+		}
+		return nil
+	}
+	if !found {
+		e = entry{expiresAt: c.expiry(now, 0)}
+	}
+	e.value, e.createdAt, e.accessed = v, now, nil
+	if !c.setLocked(key, e) {
+		return nil
+	}
+	return v
+}
+
+// Merge folds value into the entry for key under the write lock and returns the
+// result: if key has a live entry, merge(existing, value) replaces it; otherwise
+// value is stored as is. It is Update specialized for accumulating into a cached
+// value, such as adding fields to a cached map. merge runs with the lock held,
+// under the same rules as Update's fn.
+//
+// A ttl > 0 restarts the entry's lifetime at ttl, as a write would; a ttl <= 0
+// keeps the existing deadline, so that frequent merges cannot extend an entry's
+// life indefinitely, and stores a new key as Set with that ttl would. Tags and
+// weight are kept as in Update, and a refused write returns nil as in Update.
+func (c *Cache) Merge(key string, value interface{}, merge func(old, new interface{}) interface{}, ttl time.Duration) interface{} {
+	key = c.normalize(key)
+	now := c.now()
+	c.mu.Lock()
+	defer c.unlock()
+	if c.frozen.Load() {
+		return nil
+	}
+	e, ok := c.data[key]
+	if ok && e.expired(now) {
+		c.removeLocked(key, e, ReasonExpired)
+		ok = false
+	}
+	if ok && !e.negative {
+		e.value, e.createdAt, e.accessed = merge(c.copyOut(e.value), value), now, nil
+		if ttl > 0 {
+			e.expiresAt = c.expiry(now, ttl)
+		}
+	} else {
+		e = entry{value: value, expiresAt: c.expiry(now, ttl)}
+	}
+	if !c.setLocked(key, e) {
+		return nil
+	}
+	return e.value
+}
@@ -0,0 +1,142 @@
+package cache
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestUpdateIsAtomic(t *testing.T) {
+	c := New(time.Hour)
+	defer c.Stop()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			c.Update("list", func(old interface{}, found bool) (interface{}, bool) {
+				var s []int
+				if found {
+					s = old.([]int)
+				}
+				return append(s, i), true
+			})
+		}(i)
+	}
+	wg.Wait()
+	if v, _ := c.Get("list"); len(v.([]int)) != 50 {
+		t.Fatalf("expected every append to land, got %d", len(v.([]int)))
+	}
+}
+
+func TestUpdateKeepsTTLAndDeletes(t *testing.T) {
+	c := New(time.Hour)
+	defer c.Stop()
+	log := newEvictLog(c)
+
+	c.Set("k", 1, time.Minute)
+	got := c.Update("k", func(old interface{}, found bool) (interface{}, bool) {
+		if !found || old != 1 {
+			t.Fatalf("expected the live value, got %v found=%v", old, found)
+		}
+		return 2, true
+	})
+	if got != 2 {
+		t.Fatalf("expected Update to return the new value, got %v", got)
+	}
+	if v, ttl, _ := c.GetWithTTL("k"); v != 2 || ttl <= 0 {
+		t.Fatalf("expected the new value with its TTL kept, got %v (ttl %v)", v, ttl)
+	}
+
+	if got := c.Update("k", func(interface{}, bool) (interface{}, bool) { return nil, false }); got != nil {
+		t.Fatalf("expected nil after a delete, got %v", got)
+	}
+	if r, _ := log.reason("k"); c.Has("k") || r != ReasonDeleted {
+		t.Fatalf("expected keep=false to delete the entry")
+	}
+
+	c.Update("new", func(old interface{}, found bool) (interface{}, bool) {
+		if found {
+			t.Fatalf("expected a missing key to be reported as not found")
+		}
+		return "created", true
+	})
+	if v, ttl, _ := c.GetWithTTL("new"); v != "created" || ttl != 0 {
+		t.Fatalf("expected a created entry without expiry, got %v (ttl %v)", v, ttl)
+	}
+}
+
+func TestUpdateAndMergeHonourMaxTTLAndRefusedWrites(t *testing.T) {
+	c := NewWithOptions(0, WithMaxTTL(time.Minute), WithMaxValueSize(3, func(v interface{}) int64 {
+		s, _ := v.(string)
+		return int64(len(s))
+	}))
+	defer c.Stop()
+
+	set := func(v interface{}) func(interface{}, bool) (interface{}, bool) {
+		return func(interface{}, bool) (interface{}, bool) { return v, true }
+	}
+	c.Update("k", set("ab"))
+	if _, ttl, _ := c.GetWithTTL("k"); ttl <= 0 || ttl > time.Minute {
+		t.Fatalf("expected a created key to get the maximum TTL, got %v", ttl)
+	}
+	if v := c.Update("k", set("toolong")); v != nil {
+		t.Fatalf("expected a refused write to return nil, got %v", v)
+	}
+	if v, _ := c.Get("k"); v != "ab" {
+		t.Fatalf("expected the refused write to leave the entry alone, got %v", v)
+	}
+
+	concat := func(old, new interface{}) interface{} { return old.(string) + new.(string) }
+	c.Merge("m", "a", concat, 0)
+	if _, ttl, _ := c.GetWithTTL("m"); ttl <= 0 || ttl > time.Minute {
+		t.Fatalf("expected a key created by Merge to get the maximum TTL, got %v", ttl)
+	}
+	if v := c.Merge("m", "bcd", concat, 0); v != nil {
+		t.Fatalf("expected a refused merge to return nil, got %v", v)
+	}
+	if v, _ := c.Get("m"); v != "a" {
+		t.Fatalf("expected the refused merge to leave the entry alone, got %v", v)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	c := NewManual()
+	defer c.Stop()
+
+	union := func(old, new interface{}) interface{} {
+		m := map[string]int{}
+		for k, v := range old.(map[string]int) {
+			m[k] = v
+		}
+		for k, v := range new.(map[string]int) {
+			m[k] = v
+		}
+		return m
+	}
+	c.Merge("m", map[string]int{"a": 1}, union, 0)
+	if _, ttl, _ := c.GetWithTTL("m"); ttl != 0 {
+		t.Fatalf("expected a new key without expiry, got %v", ttl)
+	}
+	got := c.Merge("m", map[string]int{"b": 2}, union, time.Hour)
+	if !reflect.DeepEqual(got, map[string]int{"a": 1, "b": 2}) {
+		t.Fatalf("expected merged map, got %v", got)
+	}
+	_, ttl, _ := c.GetWithTTL("m")
+	if ttl <= 0 || ttl > time.Hour {
+		t.Fatalf("expected ttl > 0 to refresh the deadline, got %v", ttl)
+	}
+	c.Merge("m", map[string]int{"a": 3}, union, 0)
+	if v, ttl2, _ := c.GetWithTTL("m"); !reflect.DeepEqual(v, map[string]int{"a": 3, "b": 2}) || ttl2 <= 0 || ttl2 > ttl {
+		t.Fatalf("expected ttl <= 0 to keep the deadline, got %v ttl=%v", v, ttl2)
+	}
+
+	c.Set("short", map[string]int{"old": 1}, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	c.Merge("short", map[string]int{"new": 1}, union, 0)
+	if v, _ := c.Get("short"); !reflect.DeepEqual(v, map[string]int{"new": 1}) {
+		t.Fatalf("expected an expired entry to be treated as absent, got %v", v)
+	}
+}
@@ -0,0 +1,42 @@
+package cache
+
+import "time"
+
+// GetVersioned is Get, minus the read-through loader, that also returns the
+// version of the value, for an optimistic read/compute/write cycle closed by
+// SetIfVersion. Every write that stores a value under a key (Set, Update, a
+// refresh and so on) gives it a new version, drawn from a counter shared by the
+// whole cache, so versions only grow and a key that is deleted and stored again
+// never repeats one. Versions start at 1; a miss reports version 0.
+func (c *Cache) GetVersioned(key string) (value interface{}, version uint64, ok bool) {
+	key = c.normalize(key)
+	e, ok := c.get(key, c.now())
+	if !ok || e.negative {
+		return nil, 0, false
+	}
+	return e.value, e.version, true
+}
+
+// SetIfVersion stores value with ttl (never expiring if ttl <= 0) only if the
+// current version of key is expectedVersion, and reports whether it did. An
+// expectedVersion of 0 means "insert if absent": it matches a missing, expired
+// or negative entry. Unlike CompareAndSwap it detects a write that stored an
+// equal value in between. The check and the write happen under one write lock.
+func (c *Cache) SetIfVersion(key string, value interface{}, expectedVersion uint64, ttl time.Duration) bool {
+	key = c.normalize(key)
+	now := c.now()
+	c.mu.Lock()
+	defer c.unlock()
+	var current uint64
+	if e, ok := c.data[key]; ok {
+		if e.expired(now) {
+			c.removeLocked(key, e, ReasonExpired)
+		} else if !e.negative {
+			current = e.version
+		}
+	}
+	if current != expectedVersion {
+		return false
+	}
+	return c.setLocked(key, entry{value: value, expiresAt: c.expiry(now, ttl)})
+}
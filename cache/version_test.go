@@ -0,0 +1,67 @@
+package cache
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSetIfVersion(t *testing.T) {
+	c := NewManual()
+	defer c.Stop()
+
+	if _, v, ok := c.GetVersioned("k"); ok || v != 0 {
+		t.Fatalf("expected a miss at version 0, got %d ok=%v", v, ok)
+	}
+	if !c.SetIfVersion("k", 1, 0, 0) {
+		t.Fatalf("expected version 0 to insert an absent key")
+	}
+	_, v1, _ := c.GetVersioned("k")
+	c.Set("k", 1, 0) // same value, new write
+	val, v2, ok := c.GetVersioned("k")
+	if !ok || val != 1 || v2 <= v1 {
+		t.Fatalf("expected an equal-value write to bump the version, got %d then %d", v1, v2)
+	}
+	if c.SetIfVersion("k", 2, v1, 0) {
+		t.Fatalf("expected a stale version to be rejected")
+	}
+	if !c.SetIfVersion("k", 2, v2, time.Hour) {
+		t.Fatalf("expected the current version to be accepted")
+	}
+	if v, ttl, _ := c.GetWithTTL("k"); v != 2 || ttl <= 0 {
+		t.Fatalf("expected the value and TTL stored, got %v ttl=%v", v, ttl)
+	}
+
+	_, v3, _ := c.GetVersioned("k")
+	c.Delete("k")
+	c.Set("k", 3, 0)
+	if _, v4, _ := c.GetVersioned("k"); v4 <= v3 {
+		t.Fatalf("expected a recreated key not to reuse a version, got %d after %d", v4, v3)
+	}
+}
+
+func TestSetIfVersionSingleWinner(t *testing.T) {
+	c := NewManual()
+	defer c.Stop()
+	c.Set("k", 0, 0)
+	_, v, _ := c.GetVersioned("k")
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	wins := 0
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if c.SetIfVersion("k", i, v, 0) {
+				mu.Lock()
+				wins++
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+	if wins != 1 {
+		t.Fatalf("expected exactly one winner, got %d", wins)
+	}
+}
@@ -0,0 +1,90 @@
+package cache
+
+import (
+	"container/list"
+	"time"
+)
+
+// SetWeighted stores value like Set, with an eviction weight. When a capacity
+// or size bound forces an eviction, the cache removes an entry of the lowest
+// weight present, choosing the least recently used among entries of equal
+// weight; entries stored any other way have weight 0. Give cheap-to-recompute
+// entries a negative weight and precious ones a positive weight. The weight is
+// reported by GetMeta and replaced by the next write of the key. The top weight,
+// math.MaxInt, is reserved for SetWithPriority's Pinned entries: larger weights
+// are stored as math.MaxInt-1.
+//
+// Caches without a capacity or size bound record the weight but never evict.
+func (c *Cache) SetWeighted(key string, value interface{}, ttl time.Duration, weight int) {
+	c.setWeighted(key, value, ttl, min(weight, pinnedWeight-1))
+}
+
+// setWeighted is SetWeighted without the cap, so that it can store Pinned
+// entries.
+func (c *Cache) setWeighted(key string, value interface{}, ttl time.Duration, weight int) {
+	key = c.normalize(key)
+	e := entry{value: value, expiresAt: c.expiry(c.now(), ttl), weight: weight}
+	c.mu.Lock()
+	defer c.unlock()
+	c.setLocked(key, e)
+}
+
+// linkWeightLocked returns key's element in the recency list for weight, moved
+// to the front, reusing old's element when key kept its weight. c.mu must be
+// held for writing.
+func (c *Cache) linkWeightLocked(key string, old entry, weight int) *list.Element {
+	if old.welem != nil {
+		if old.weight == weight {
+			c.weighted[weight].MoveToFront(old.welem)
+			return old.welem
+		}
+		c.unlinkWeightLocked(old)
+	}
+	l := c.weighted[weight]
+	if l == nil {
+		if c.weighted == nil {
+			c.weighted = make(map[int]*list.List)
+		}
+		l = list.New()
+		c.weighted[weight] = l
+	}
+	return l.PushFront(key)
+}
+
+// unlinkWeightLocked removes e from its weight's recency list, dropping the list
+// once empty. c.mu must be held for writing.
+func (c *Cache) unlinkWeightLocked(e entry) {
+	l := c.weighted[e.weight]
+	l.Remove(e.welem)
+	if l.Len() == 0 {
+		delete(c.weighted, e.weight)
+	}
+}
+
+// victimLocked picks the least-recently-used entry of the lowest weight, other
+// than keep if protect is set, or asks the eviction policy if there is one.
+// Every weight, 0 included, has its own recency list, so the victim is always
+// near the tail of one of them. c.mu must be held.
+func (c *Cache) victimLocked(keep string, protect bool) (string, bool) {
+	if c.policy != nil {
+		k := c.policy.Victim()
+		if _, ok := c.data[k]; !ok || protect && k == keep {
+			return "", false
+		}
+		return k, true
+	}
+	victim, weight, found := "", 0, false
+	for w, l := range c.weighted {
+		if found && w >= weight {
+			continue
+		}
+		el := l.Back()
+		if el != nil && protect && el.Value.(string) == keep {
+			el = el.Prev()
+		}
+		if el != nil {
+			victim, weight, found = el.Value.(string), w, true
+		}
+	}
+	return victim, found
+}
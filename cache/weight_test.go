@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSetWeightedEvictsLowestWeightFirst(t *testing.T) {
+	c := NewWithCapacity(time.Hour, 3)
+	defer c.Stop()
+	log := newEvictLog(c)
+
+	c.SetWeighted("precious", 1, 0, 10)
+	c.Set("a", 1, 0)
+	c.Set("b", 1, 0)
+	c.Set("c", 1, 0) // precious is least recent, but a has the lower weight
+	if r, _ := log.reason("a"); r != ReasonCapacity || !c.Has("precious") {
+		t.Fatalf("expected a to be evicted ahead of the weighted entry")
+	}
+
+	c.SetWeighted("cheap", 1, 0, -1) // evicts b, the LRU weight 0 entry
+	c.Set("d", 1, 0)                 // evicts cheap despite its recency
+	rb, _ := log.reason("b")
+	rc, _ := log.reason("cheap")
+	if rb != ReasonCapacity || rc != ReasonCapacity {
+		t.Fatalf("expected b and then cheap to be evicted")
+	}
+
+	c.Set("e", 1, 0) // only precious and weight 0 entries remain: c goes
+	for _, k := range []string{"precious", "d", "e"} {
+		if !c.Has(k) {
+			t.Fatalf("expected %s to survive", k)
+		}
+	}
+	if m, _ := c.GetMeta("precious"); m.Weight != 10 {
+		t.Fatalf("expected GetMeta to report weight 10, got %d", m.Weight)
+	}
+
+	c.Set("precious", 2, 0) // a plain write resets the weight
+	if m, _ := c.GetMeta("precious"); m.Weight != 0 {
+		t.Fatalf("expected Set to reset the weight, got %d", m.Weight)
+	}
+	if _, ok := c.weighted[10]; ok || len(c.weighted) != 1 {
+		t.Fatalf("expected empty weight lists to be dropped, got %d", len(c.weighted))
+	}
+}
+
+func TestSetWeightedTiesBreakByRecency(t *testing.T) {
+	c := NewWithCapacity(time.Hour, 2)
+	defer c.Stop()
+
+	c.SetWeighted("x", 1, 0, 5)
+	c.SetWeighted("y", 1, 0, 5)
+	c.Get("x")
+	c.SetWeighted("z", 1, 0, 5)
+	if c.Has("y") || !c.Has("x") || !c.Has("z") {
+		t.Fatalf("expected the least recently used of equal weights to go, got %v", c.Keys())
+	}
+}
+
+func BenchmarkSetWithPinnedEntries(b *testing.B) {
+	c := NewWithCapacity(time.Hour, 10100)
+	defer c.Stop()
+	for i := 0; i < 10000; i++ {
+		c.SetWithPriority("pinned"+strconv.Itoa(i), i, 0, Pinned)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.Set(strconv.Itoa(i), i, 0)
+	}
+}
@@ -0,0 +1,90 @@
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+// defaultRefreshWorkers bounds how many background refreshes and revalidations
+// run at once unless WithRefreshWorkers says otherwise.
+const defaultRefreshWorkers = 8
+
+// workPool runs submitted tasks on at most limit goroutines, queueing the
+// excess. Workers are started on demand and exit as soon as the queue is empty,
+// so an idle pool holds no goroutines. The zero value is ready to use.
+type workPool struct {
+	mu      sync.Mutex
+	limit   int      // <= 0 means defaultRefreshWorkers; set before first use
+	queue   []func() // Tasks not yet picked up by a worker
+	running int      // Live workers
+	closed  bool
+	wg      sync.WaitGroup
+}
+
+// submit queues task and reports whether it was accepted; it is refused once
+// the pool is closed. It never blocks on the task, so it may be called with
+// other locks held.
+func (p *workPool) submit(task func()) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return false
+	}
+	p.queue = append(p.queue, task)
+	limit := p.limit
+	if limit <= 0 {
+		limit = defaultRefreshWorkers
+	}
+	if p.running < limit {
+		p.running++
+		p.wg.Add(1)
+		go p.work()
+	}
+	return true
+}
+
+// work runs queued tasks until the queue is empty or the pool is closed.
+func (p *workPool) work() {
+	defer p.wg.Done()
+	for {
+		p.mu.Lock()
+		if p.closed || len(p.queue) == 0 {
+			p.running--
+			p.mu.Unlock()
+			return
+		}
+		task := p.queue[0]
+		p.queue[0] = nil
+		p.queue = p.queue[1:]
+		p.mu.Unlock()
+		task()
+	}
+}
+
+// close refuses further tasks, drops the queued ones and waits for running
+// tasks to return.
+func (p *workPool) close() {
+	p.mu.Lock()
+	p.closed = true
+	p.queue = nil
+	p.mu.Unlock()
+	p.wg.Wait()
+}
+
+// NewWithRefreshWorkers creates a Cache that runs at most workers background
+// refreshes at once; see WithRefreshWorkers.
+func NewWithRefreshWorkers(cleanerInterval time.Duration, workers int) *Cache {
+	return NewWithOptions(cleanerInterval, WithRefreshWorkers(workers))
+}
+
+// WithRefreshWorkers bounds the background reload work of the cache, the
+// SetRefreshing refreshes and the OnStale revalidations together, to workers
+// goroutines; further due reloads queue until a worker is free. The workers
+// exit when there is nothing to do, and Stop drops queued reloads and waits for
+// running ones, so no goroutine outlives it. A workers <= 0 means the default
+// of 8.
+func WithRefreshWorkers(workers int) Option {
+	return func(c *Cache) {
+		c.refreshPool.limit = workers
+	}
+}
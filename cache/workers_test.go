@@ -0,0 +1,81 @@
+package cache
+
+import (
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRefreshWorkersBoundConcurrency(t *testing.T) {
+	c := NewWithRefreshWorkers(time.Hour, 2)
+	defer c.Stop()
+
+	var running, peak, calls int64
+	var first sync.Map
+	for i := 0; i < 6; i++ {
+		key := strconv.Itoa(i)
+		// Long enough that every queued refresh starts before its entry expires.
+		err := c.SetRefreshing(key, 400*time.Millisecond, func() (interface{}, error) {
+			if _, loaded := first.LoadOrStore(key, true); !loaded {
+				return 0, nil // the synchronous first load
+			}
+			n := atomic.AddInt64(&running, 1)
+			for {
+				p := atomic.LoadInt64(&peak)
+				if n <= p || atomic.CompareAndSwapInt64(&peak, p, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			atomic.AddInt64(&running, -1)
+			atomic.AddInt64(&calls, 1)
+			return 1, nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	time.Sleep(450 * time.Millisecond)
+	if n := atomic.LoadInt64(&calls); n < 6 {
+		t.Fatalf("expected queued refreshes to run eventually, got %d calls", n)
+	}
+	if p := atomic.LoadInt64(&peak); p > 2 {
+		t.Fatalf("expected at most 2 concurrent refreshes, saw %d", p)
+	}
+}
+
+func TestRefreshWorkersStopWithoutLeaks(t *testing.T) {
+	startG := runtime.NumGoroutine()
+	c := NewWithRefreshWorkers(time.Hour, 3)
+	c.OnStale(func(string) (interface{}, error) {
+		time.Sleep(5 * time.Millisecond)
+		return 1, nil
+	})
+	for i := 0; i < 20; i++ {
+		key := strconv.Itoa(i)
+		c.SetRefreshing(key, 10*time.Millisecond, func() (interface{}, error) {
+			time.Sleep(2 * time.Millisecond)
+			return 1, nil
+		})
+		c.SetWithStale("stale"+key, 1, time.Millisecond, time.Hour)
+	}
+	time.Sleep(20 * time.Millisecond)
+	for i := 0; i < 20; i++ {
+		c.Get("stale" + strconv.Itoa(i)) // queues revalidations behind the refreshes
+	}
+	c.Stop()
+
+	p := &c.refreshPool
+	p.mu.Lock()
+	running, queued, closed := p.running, len(p.queue), p.closed
+	p.mu.Unlock()
+	if running != 0 || queued != 0 || !closed {
+		t.Fatalf("expected Stop to close the pool with no workers left, got running=%d queued=%d closed=%v",
+			running, queued, closed)
+	}
+	waitForGoroutines(t, startG)
+}
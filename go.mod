@@ -0,0 +1,3 @@
+module github.com/UtkrushtApps/go-concurrent-cache-answers
+
+go 1.22